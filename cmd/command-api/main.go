@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,10 +10,107 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+	"github.com/HarshavardhanK/espm/internal/services"
+	"github.com/HarshavardhanK/espm/pkg/compress"
+	"github.com/HarshavardhanK/espm/pkg/telemetry"
 	"github.com/gin-gonic/gin"
 )
 
+// commandRetries is how often a command is retried after losing a concurrency race
+const commandRetries = 3
+
 func main() {
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := postgres.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	payloadSerializer, err := events.SerializerFor(events.Format(cfg.Events.PayloadFormat))
+	if err != nil {
+		log.Fatalf("Invalid event payload format: %v", err)
+	}
+	order.EventTypes().WithSerializer(payloadSerializer)
+
+	upcasters := order.Upcasters()
+
+	// Redis is an optimization, the API keeps working against Postgres without it
+	var store repository.EventStore = postgres.NewPostgresEventStore(db).
+		WithUpcasters(upcasters).
+		WithStrictTypes(order.EventTypes())
+
+	codec, err := compress.CodecFor(compress.Algorithm(cfg.Snapshots.Compression))
+	if err != nil {
+		log.Fatalf("Invalid snapshot compression: %v", err)
+	}
+
+	snapshots := postgres.NewPostgresSnapshotStore(db).WithCompression(codec, cfg.Snapshots.CompressAbove)
+
+	var snapshotStore repository.SnapshotStore = snapshots
+
+	redisCache, err := cache.NewRedisCache(cfg.Redis)
+	if err != nil {
+		log.Printf("Warning: running without event cache: %v", err)
+	} else {
+		defer redisCache.Close()
+		serializer, err := events.SerializerFor(events.Format(cfg.Redis.Format))
+		if err != nil {
+			log.Fatalf("Invalid cache format: %v", err)
+		}
+
+		store = repository.NewCachedEventStore(store, redisCache, cfg.Redis.TTL).
+			WithUpcasters(upcasters).
+			WithSerializer(serializer).
+			WithReloadLock(cfg.Redis.LockTTL, cfg.Redis.LockWait)
+
+		snapshotStore = repository.NewCachedSnapshotStore(snapshots, redisCache)
+	}
+
+	orders := repository.NewAggregateRepository[*order.Order](
+		order.AggregateType,
+		store,
+		snapshotStore,
+		order.Empty,
+		repository.PolicyFromConfig(cfg.Snapshots.Policies[order.AggregateType]),
+	)
+
+	var snapshotter *repository.AsyncSnapshotter
+	if cfg.Snapshots.Workers > 0 {
+		snapshotter = repository.NewAsyncSnapshotter(cfg.Snapshots.Workers, cfg.Snapshots.QueueSize)
+		orders.WithAsyncSnapshots(snapshotter)
+	}
+
+	regenerateCtx, stopRegenerating := context.WithCancel(context.Background())
+	defer stopRegenerating()
+
+	if cfg.Snapshots.RegenerateOutdated {
+		go func() {
+			regenerated, err := orders.RegenerateSnapshots(regenerateCtx, cfg.Snapshots.RegenerateBatchSize)
+			if err != nil && regenerateCtx.Err() == nil {
+				log.Printf("Warning: failed to regenerate outdated snapshots: %v", err)
+			}
+			log.Printf("Regenerated %d outdated order snapshots", regenerated)
+		}()
+	}
+
+	if cfg.Snapshots.Retain > 0 && cfg.Snapshots.PruneInterval > 0 {
+		go pruneSnapshots(snapshots, order.AggregateType, cfg.Snapshots.Retain, cfg.Snapshots.PruneInterval)
+	}
+
+	handlers := api.NewOrderCommandHandlers(services.NewOrderCommandService(orders, commandRetries))
+
 	// Create a new Gin router
 	r := gin.Default()
 
@@ -23,10 +121,21 @@ func main() {
 		})
 	})
 
+	idempotency := postgres.NewPostgresIdempotencyStore(db)
+	go purgeIdempotencyKeys(idempotency)
+
+	handlers.Register(r.Group("/api",
+		api.Tracing(),
+		api.Idempotency(idempotency, cfg.Server.IdempotencyWindow),
+	))
+
 	// Start the server in a goroutine
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -35,6 +144,17 @@ func main() {
 		}
 	}()
 
+	metricsSrv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.MetricsPort),
+		Handler: telemetry.DefaultRegistry.Handler(),
+	}
+
+	go func() {
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Warning: metrics server stopped: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -48,5 +168,33 @@ func main() {
 		log.Fatal("Server forced to shutdown:", err)
 	}
 
+	stopRegenerating()
+
+	if snapshotter != nil {
+		if err := snapshotter.Close(ctx); err != nil {
+			log.Printf("Warning: pending snapshots were not written: %v", err)
+		}
+	}
+
+	metricsSrv.Shutdown(ctx)
+
 	log.Println("Server exiting")
 }
+
+// pruneSnapshots periodically deletes all but the newest keep snapshots of each aggregate
+func pruneSnapshots(store *postgres.PostgresSnapshotStore, aggregateType string, keep int, interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := store.PruneSnapshots(context.Background(), aggregateType, keep); err != nil {
+			log.Printf("Warning: failed to prune %s snapshots: %v", aggregateType, err)
+		}
+	}
+}
+
+// purgeIdempotencyKeys periodically deletes expired idempotency keys
+func purgeIdempotencyKeys(store *postgres.PostgresIdempotencyStore) {
+	for range time.Tick(time.Hour) {
+		if _, err := store.PurgeExpired(context.Background()); err != nil {
+			log.Printf("Warning: failed to purge idempotency keys: %v", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+)
+
+// event-migrate upcasts stored event payloads offline.
+//
+// In verify mode it reports how many events are behind their latest schema
+// and fails if any of them cannot be upcast to a payload that strictly
+// matches its registered type. In rewrite mode it also stores
+// the upcast payloads, so reads no longer pay for the transforms.
+// A run that stops early logs the last position it handled; pass it as
+// -from to continue.
+func main() {
+	mode := flag.String("mode", "verify", "verify or rewrite")
+	batchSize := flag.Int("batch", repository.DefaultBatchSize, "events read per batch")
+	from := flag.Int64("from", 0, "skip events at or before this global position")
+	flag.Parse()
+
+	if *mode != "verify" && *mode != "rewrite" {
+		log.Fatalf("Unknown mode %q, expected verify or rewrite", *mode)
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := postgres.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Read raw history; the migration applies the registry explicitly
+	migration := repository.PayloadMigration{
+		Store:      postgres.NewPostgresEventStore(db),
+		Upcasters:  order.Upcasters(),
+		EventTypes: order.EventTypes(),
+		Rewrite:    *mode == "rewrite",
+		From:       *from,
+		BatchSize:  *batchSize,
+	}
+
+	report, runErr := migration.Run(context.Background())
+
+	for _, failure := range report.Failures {
+		event := failure.Event
+		log.Printf("Event %s (%s v%d) at position %d: %v", event.EventID, event.EventType, event.EventVersion, event.Position, failure.Err)
+	}
+
+	log.Printf("Scanned %d events up to position %d: %d outdated, %d rewritten, %d failed",
+		report.Scanned, report.LastPosition, report.Outdated, report.Rewritten, len(report.Failures))
+
+	if runErr != nil {
+		log.Fatalf("Stopped: %v; rerun with -from %d to continue", runErr, report.LastPosition)
+	}
+
+	if len(report.Failures) > 0 {
+		os.Exit(1)
+	}
+}
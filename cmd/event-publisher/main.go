@@ -2,20 +2,81 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-
 	"net/http"
-
 	"os"
 	"os/signal"
-
 	"syscall"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/outbox"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+	"github.com/HarshavardhanK/espm/pkg/messaging"
 	"github.com/gin-gonic/gin"
 )
 
+// logPublisher writes messages to the service log
+type logPublisher struct{}
+
+func (logPublisher) Publish(ctx context.Context, msg messaging.Message) error {
+	log.Printf("publish topic=%s key=%s type=%s value=%s", msg.Topic, msg.Key, msg.Headers["event-type"], msg.Value)
+	return nil
+}
+
+func (logPublisher) Close() error {
+	return nil
+}
+
+// newPublisher builds the sink selected in the config
+func newPublisher(cfg config.OutboxConfig) (messaging.Publisher, error) {
+	switch cfg.Sink {
+	case "file":
+		return messaging.NewFilePublisher(cfg.FilePath)
+	case "log":
+		return logPublisher{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox sink %q", cfg.Sink)
+	}
+}
+
 func main() {
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := postgres.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	publisher, err := newPublisher(cfg.Outbox)
+	if err != nil {
+		log.Fatalf("Failed to create publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	relay, err := outbox.NewRelay(postgres.NewPostgresOutboxStore(db), publisher, cfg.Outbox)
+	if err != nil {
+		log.Fatalf("Failed to create outbox relay: %v", err)
+	}
+
+	// Publish as soon as events are committed instead of waiting for the next poll
+	relay.WithNotifier(postgres.NewPostgresNotifier(cfg.Database.DSN(), time.Second, time.Minute))
+
+	runCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+
+	go func() {
+		defer close(relayDone)
+		if err := relay.Run(runCtx); err != nil {
+			log.Printf("Outbox relay stopped: %v", err)
+		}
+	}()
+
 	// Create a new Gin router
 	r := gin.Default()
 
@@ -28,8 +89,11 @@ func main() {
 
 	// Start the server in a goroutine
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -45,6 +109,10 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	// Stop the relay first so its leases are released for other replicas
+	stopRelay()
+	<-relayDone
+
 	// The context is used to inform the server it has 5 seconds to finish
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/projections"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// projectionHandlers exposes projection status and control over HTTP
+type projectionHandlers struct {
+	runner *projections.Runner
+	store  repository.ProjectionStore
+}
+
+// register adds the projection routes to a router group
+func (h *projectionHandlers) register(r gin.IRouter) {
+	r.GET("/projections", h.listProjections)
+	r.POST("/projections/:name/rebuild", h.rebuildProjection)
+}
+
+func (h *projectionHandlers) listProjections(c *gin.Context) {
+	infos, err := h.store.ListProjections(c.Request.Context())
+	if err != nil {
+		log.Printf("failed to list projections: %v", err)
+		api.WriteProblem(c, http.StatusInternalServerError, "Internal server error", "")
+		return
+	}
+
+	if infos == nil {
+		infos = []repository.ProjectionInfo{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"projections": infos})
+}
+
+func (h *projectionHandlers) rebuildProjection(c *gin.Context) {
+	name := c.Param("name")
+
+	if err := h.runner.Rebuild(name); err != nil {
+		if errors.Is(err, repository.ErrProjectionNotFound) {
+			api.WriteProblem(c, http.StatusNotFound, "Projection not found", name)
+			return
+		}
+		api.WriteProblem(c, http.StatusInternalServerError, "Internal server error", "")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
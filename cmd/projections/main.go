@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,10 +10,51 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/projections"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := postgres.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	eventStore := postgres.NewPostgresEventStore(db).WithUpcasters(order.Upcasters())
+	store := postgres.NewPostgresProjectionStore(db)
+
+	// Projections are woken by commit notifications and poll while the listener is down
+	notifier := postgres.NewPostgresNotifier(cfg.Database.DSN(), time.Second, time.Minute)
+	subscriber := repository.NewStoreSubscriber(eventStore, notifier, repository.DefaultSubscriberConfig())
+	runner := projections.NewRunner(subscriber, store, projections.DefaultRunnerConfig())
+
+	if err := runner.Register(projections.NewOrderViewProjection()); err != nil {
+		log.Fatalf("Failed to register projection: %v", err)
+	}
+
+	runCtx, stopRunner := context.WithCancel(context.Background())
+	runnerDone := make(chan struct{})
+
+	go func() {
+		defer close(runnerDone)
+		runner.Run(runCtx)
+	}()
+
+	handlers := &projectionHandlers{
+		runner: runner,
+		store:  store,
+	}
+
 	// Create a new Gin router
 	r := gin.Default()
 
@@ -23,10 +65,15 @@ func main() {
 		})
 	})
 
+	handlers.register(r.Group("/api"))
+
 	// Start the server in a goroutine
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -37,17 +84,21 @@ func main() {
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
-
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
 	<-quit
 
 	log.Println("Shutting down server...")
 
+	// Stop the projections first; an in-flight batch is rolled back and replayed on restart
+	stopRunner()
+	<-runnerDone
+
 	// The context is used to inform the server it has 5 seconds to finish
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
-	
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,10 +10,63 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := postgres.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	upcasters := order.Upcasters()
+
+	// Current state is served from projections. Only history and point-in-time
+	// reads, which no projection can answer, go to the event store.
+	var eventStore repository.EventStore = postgres.NewPostgresEventStore(db).WithUpcasters(upcasters)
+
+	// Redis is an optimization, the API keeps working against Postgres without it
+	redisCache, err := cache.NewRedisCache(cfg.Redis)
+	if err != nil {
+		log.Printf("Warning: running without event cache: %v", err)
+	} else {
+		defer redisCache.Close()
+		serializer, err := events.SerializerFor(events.Format(cfg.Redis.Format))
+		if err != nil {
+			log.Fatalf("Invalid cache format: %v", err)
+		}
+
+		eventStore = repository.NewCachedEventStore(eventStore, redisCache, cfg.Redis.TTL).
+			WithUpcasters(upcasters).
+			WithSerializer(serializer).
+			WithReloadLock(cfg.Redis.LockTTL, cfg.Redis.LockWait)
+	}
+
+	handlers := api.NewOrderQueryHandlers(
+		postgres.NewPostgresOrderViewStore(db),
+		eventStore,
+		repository.NewAggregateRepository[*order.Order](
+			order.AggregateType,
+			eventStore,
+			postgres.NewPostgresSnapshotStore(db),
+			order.Empty,
+			nil,
+		),
+	)
+
 	// Create a new Gin router
 	r := gin.Default()
 
@@ -23,9 +77,15 @@ func main() {
 		})
 	})
 
+	handlers.Register(r.Group("/api"))
+
+	// Start the server in a goroutine
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_events_aggregate ON events (aggregate_type, aggregate_id);
+
+DROP INDEX IF EXISTS idx_events_stream_sequence;
@@ -0,0 +1,5 @@
+-- Sequence numbers are unique within a stream, so concurrent writers can never
+-- commit the same version twice. This index supersedes idx_events_aggregate.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_stream_sequence ON events (aggregate_type, aggregate_id, sequence_number);
+
+DROP INDEX IF EXISTS idx_events_aggregate;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_global_position;
+
+ALTER TABLE events DROP COLUMN IF EXISTS global_position;
+
+DROP SEQUENCE IF EXISTS events_global_position_seq;
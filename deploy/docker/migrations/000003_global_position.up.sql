@@ -0,0 +1,18 @@
+-- Global commit position, assigned in commit order by the event store
+CREATE SEQUENCE IF NOT EXISTS events_global_position_seq;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS global_position BIGINT;
+
+-- Backfill existing events in creation order
+WITH ordered AS (
+    SELECT event_id, row_number() OVER (ORDER BY created_at, sequence_number) AS position
+    FROM events
+)
+UPDATE events e
+SET global_position = o.position
+FROM ordered o
+WHERE e.event_id = o.event_id;
+
+SELECT setval('events_global_position_seq', COALESCE((SELECT MAX(global_position) FROM events), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_global_position ON events (global_position);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_events_type_position;
+DROP INDEX IF EXISTS idx_events_sequence_position;
@@ -0,0 +1,3 @@
+-- Keyset pagination indexes for streaming reads
+CREATE INDEX IF NOT EXISTS idx_events_type_position ON events (event_type, global_position);
+CREATE INDEX IF NOT EXISTS idx_events_sequence_position ON events (sequence_number, global_position);
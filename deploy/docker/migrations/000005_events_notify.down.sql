@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS events_appended_notify ON events;
+
+DROP FUNCTION IF EXISTS notify_events_appended();
@@ -0,0 +1,16 @@
+-- Notify subscribers once a transaction's events have been positioned.
+-- The payload is empty so notifications within a transaction collapse into one.
+CREATE OR REPLACE FUNCTION notify_events_appended() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('events_appended', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS events_appended_notify ON events;
+
+CREATE TRIGGER events_appended_notify
+AFTER UPDATE OF global_position ON events
+FOR EACH ROW
+WHEN (NEW.global_position IS NOT NULL)
+EXECUTE FUNCTION notify_events_appended();
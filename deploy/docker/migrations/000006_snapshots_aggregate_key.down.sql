@@ -0,0 +1,16 @@
+-- Back to the initial schema's snapshot_id key, if the table was reshaped
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'snapshots' AND column_name = 'version'
+    ) THEN
+        ALTER TABLE snapshots DROP CONSTRAINT snapshots_pkey;
+        ALTER TABLE snapshots RENAME COLUMN version TO aggregate_version;
+        ALTER TABLE snapshots ALTER COLUMN aggregate_version TYPE INTEGER;
+        ALTER TABLE snapshots ADD COLUMN snapshot_id UUID NOT NULL DEFAULT gen_random_uuid();
+        ALTER TABLE snapshots ALTER COLUMN snapshot_id DROP DEFAULT;
+        ALTER TABLE snapshots ADD PRIMARY KEY (snapshot_id);
+        ALTER TABLE snapshots ADD COLUMN metadata JSONB;
+    END IF;
+END $$;
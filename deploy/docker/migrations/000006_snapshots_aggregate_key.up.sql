@@ -0,0 +1,16 @@
+-- Key snapshots by aggregate and record the version they were taken at under
+-- the column names the snapshot store uses. The initial schema's snapshot_id
+-- and metadata columns were never written, and only the newest snapshot of
+-- each aggregate is kept.
+ALTER TABLE snapshots DROP COLUMN IF EXISTS snapshot_id;
+ALTER TABLE snapshots DROP COLUMN IF EXISTS metadata;
+ALTER TABLE snapshots RENAME COLUMN aggregate_version TO version;
+ALTER TABLE snapshots ALTER COLUMN version TYPE BIGINT;
+
+DELETE FROM snapshots s
+USING snapshots newer
+WHERE newer.aggregate_type = s.aggregate_type
+    AND newer.aggregate_id = s.aggregate_id
+    AND (newer.version, newer.created_at, newer.ctid) > (s.version, s.created_at, s.ctid);
+
+ALTER TABLE snapshots ADD PRIMARY KEY (aggregate_type, aggregate_id);
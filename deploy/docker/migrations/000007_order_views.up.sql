@@ -0,0 +1,18 @@
+-- Order read model served by the query API
+CREATE TABLE IF NOT EXISTS order_views (
+    order_id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    items JSONB NOT NULL DEFAULT '[]',
+    item_count INTEGER NOT NULL DEFAULT 0,
+    total_amount DOUBLE PRECISION NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_views_customer ON order_views (customer_id, created_at, order_id);
+CREATE INDEX IF NOT EXISTS idx_order_views_status ON order_views (status, created_at, order_id);
+CREATE INDEX IF NOT EXISTS idx_order_views_created ON order_views (created_at, order_id);
+CREATE INDEX IF NOT EXISTS idx_order_views_updated ON order_views (updated_at, order_id);
+CREATE INDEX IF NOT EXISTS idx_order_views_total ON order_views (total_amount, order_id);
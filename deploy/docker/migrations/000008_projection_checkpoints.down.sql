@@ -0,0 +1,21 @@
+ALTER TABLE projections DROP COLUMN IF EXISTS last_error;
+ALTER TABLE projections DROP COLUMN IF EXISTS checkpoint;
+ALTER TABLE projections DROP COLUMN IF EXISTS state;
+
+UPDATE projections SET status = 'active' WHERE status = 'running';
+ALTER TABLE projections ALTER COLUMN status SET DEFAULT 'active';
+
+-- Back to the initial schema's id and type key, if the table was reshaped
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'projections' AND column_name = 'projection_name'
+    ) THEN
+        UPDATE projections SET projection_type = projection_name WHERE projection_type IS NULL;
+        ALTER TABLE projections DROP COLUMN projection_name;
+    END IF;
+END $$;
+
+ALTER TABLE projections ALTER COLUMN projection_type SET NOT NULL;
+ALTER TABLE projections ALTER COLUMN projection_id DROP DEFAULT;
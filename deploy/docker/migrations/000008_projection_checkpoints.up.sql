@@ -0,0 +1,16 @@
+-- Key projections by name, as the projection runner does, instead of by id and type
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS projection_name VARCHAR(255);
+UPDATE projections SET projection_name = projection_type || ':' || projection_id WHERE projection_name IS NULL;
+ALTER TABLE projections ALTER COLUMN projection_name SET NOT NULL;
+ALTER TABLE projections ADD CONSTRAINT projections_projection_name_key UNIQUE (projection_name);
+
+ALTER TABLE projections ALTER COLUMN projection_id SET DEFAULT gen_random_uuid();
+ALTER TABLE projections ALTER COLUMN projection_type DROP NOT NULL;
+
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS state JSONB NOT NULL DEFAULT '{}';
+
+-- Durable checkpoints and status for the projection runner
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS checkpoint BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE projections ALTER COLUMN status SET DEFAULT 'running';
+UPDATE projections SET status = 'running' WHERE status = 'active';
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
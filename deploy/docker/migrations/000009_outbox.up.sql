@@ -0,0 +1,22 @@
+-- Transactional outbox drained by the event publisher.
+-- Rows are written in the same transaction as their events and reference them by ID.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events (event_id) ON DELETE CASCADE,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    lease_owner VARCHAR(255),
+    leased_until TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Pending messages per aggregate, in publish order
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (aggregate_type, aggregate_id, id)
+    WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_published ON outbox (published_at)
+    WHERE published_at IS NOT NULL;
@@ -0,0 +1,22 @@
+-- Binary payloads have no JSON form, so the migration cannot be reversed once
+-- msgpack or protobuf events are stored. Refuse instead of dropping them.
+DO $$
+DECLARE
+    binary_events BIGINT;
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'events' AND column_name = 'payload'
+    ) THEN
+        EXECUTE 'SELECT count(*) FROM events WHERE payload IS NOT NULL' INTO binary_events;
+
+        IF binary_events > 0 THEN
+            RAISE EXCEPTION 'cannot revert event payload formats: % events have binary payloads', binary_events
+                USING HINT = 'Rewrite them as JSON or restore from a backup taken before this migration.';
+        END IF;
+    END IF;
+END $$;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_payload_present;
+ALTER TABLE events DROP COLUMN IF EXISTS payload;
+ALTER TABLE events ALTER COLUMN data SET NOT NULL;
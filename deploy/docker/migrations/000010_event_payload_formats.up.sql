@@ -0,0 +1,8 @@
+-- Binary payload formats (protobuf, msgpack) are stored in payload; JSON stays in data.
+-- The format of each event is recorded in its metadata.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS payload BYTEA;
+ALTER TABLE events ALTER COLUMN data DROP NOT NULL;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_payload_present;
+ALTER TABLE events ADD CONSTRAINT events_payload_present
+    CHECK ((data IS NULL) <> (payload IS NULL));
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_events_trace;
+DROP INDEX IF EXISTS idx_events_user;
+DROP INDEX IF EXISTS idx_events_causation;
+DROP INDEX IF EXISTS idx_events_correlation;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS trace_id,
+    DROP COLUMN IF EXISTS user_id,
+    DROP COLUMN IF EXISTS causation_id,
+    DROP COLUMN IF EXISTS correlation_id;
@@ -0,0 +1,15 @@
+-- Tracing columns, empty for events recorded outside a request
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS causation_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS user_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS trace_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_events_correlation ON events(correlation_id, global_position)
+    WHERE correlation_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_events_causation ON events(causation_id)
+    WHERE causation_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_events_user ON events(user_id, global_position)
+    WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_events_trace ON events(trace_id)
+    WHERE trace_id IS NOT NULL;
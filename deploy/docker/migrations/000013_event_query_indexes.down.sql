@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_events_metadata;
+DROP INDEX IF EXISTS idx_events_created_position;
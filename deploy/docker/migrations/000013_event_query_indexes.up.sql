@@ -0,0 +1,3 @@
+-- Support ad hoc event queries by creation time and metadata
+CREATE INDEX IF NOT EXISTS idx_events_created_position ON events (created_at, global_position);
+CREATE INDEX IF NOT EXISTS idx_events_metadata ON events USING GIN (metadata jsonb_path_ops);
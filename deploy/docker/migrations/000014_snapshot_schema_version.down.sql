@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_snapshots_schema;
+ALTER TABLE snapshots DROP COLUMN IF EXISTS schema_version;
@@ -0,0 +1,5 @@
+-- Snapshots record the schema version of the aggregate state they hold.
+-- Existing snapshots get version 0 and are rebuilt from events when read.
+ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_snapshots_schema ON snapshots (aggregate_type, schema_version, aggregate_id);
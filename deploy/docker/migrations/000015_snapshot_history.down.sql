@@ -0,0 +1,9 @@
+-- Back to a single snapshot per aggregate, keeping the newest
+DELETE FROM snapshots s
+USING snapshots newer
+WHERE newer.aggregate_type = s.aggregate_type
+    AND newer.aggregate_id = s.aggregate_id
+    AND newer.version > s.version;
+
+ALTER TABLE snapshots DROP CONSTRAINT IF EXISTS snapshots_pkey;
+ALTER TABLE snapshots ADD PRIMARY KEY (aggregate_type, aggregate_id);
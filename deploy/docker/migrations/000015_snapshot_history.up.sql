@@ -0,0 +1,3 @@
+-- Keep several snapshots per aggregate, keyed by the version they were taken at
+ALTER TABLE snapshots DROP CONSTRAINT IF EXISTS snapshots_pkey;
+ALTER TABLE snapshots ADD PRIMARY KEY (aggregate_type, aggregate_id, version);
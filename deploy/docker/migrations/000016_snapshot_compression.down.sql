@@ -0,0 +1,5 @@
+-- Compressed snapshots cannot be kept, they are rebuilt from events
+DELETE FROM snapshots WHERE data IS NULL;
+ALTER TABLE snapshots DROP COLUMN IF EXISTS compression;
+ALTER TABLE snapshots DROP COLUMN IF EXISTS compressed_data;
+ALTER TABLE snapshots ALTER COLUMN data SET NOT NULL;
@@ -0,0 +1,5 @@
+-- Large snapshots may be stored compressed in compressed_data instead of data.
+-- compression names the algorithm, 'none' for snapshots kept as JSONB.
+ALTER TABLE snapshots ALTER COLUMN data DROP NOT NULL;
+ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS compressed_data BYTEA;
+ALTER TABLE snapshots ADD COLUMN IF NOT EXISTS compression VARCHAR(16) NOT NULL DEFAULT 'none';
@@ -0,0 +1,3 @@
+-- Identifies the request holding an idempotency key, so a request whose stale
+-- reservation was taken over cannot complete or release the new one.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS reservation_token UUID;
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderIdempotencyKey carries the client-chosen key of a retryable request
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks responses replayed from an earlier request
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes requests carrying an Idempotency-Key safe to retry within
+// window: the first response is stored and replayed for repeats of the same
+// request, while reusing a key for a different request is rejected.
+// Server errors and lost concurrency races are not stored, so such requests
+// can be retried.
+func Idempotency(store repository.IdempotencyStore, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			WriteProblem(c, http.StatusBadRequest, "Invalid idempotency key", "key is longer than 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			WriteProblem(c, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+
+		record, reserved, err := store.Reserve(c.Request.Context(), key, hash, window)
+		if err != nil {
+			log.Printf("failed to reserve idempotency key: %v", err)
+			WriteProblem(c, http.StatusInternalServerError, "Internal server error", "")
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != hash:
+				WriteProblem(c, http.StatusUnprocessableEntity, "Idempotency key reused", "the key was used for a different request")
+			case !record.Completed:
+				WriteProblem(c, http.StatusConflict, "Request in progress", "a request with this idempotency key is still running")
+			default:
+				c.Header(HeaderIdempotentReplayed, "true")
+				c.Data(record.StatusCode, record.ContentType, record.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The outcome must be recorded even if the client has gone away
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		if retryable(c) {
+			if err := store.Release(ctx, key, record.Token); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		err = store.Complete(ctx, key, record.Token, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// retryable reports whether a response must not be replayed because a retry
+// of the same request can succeed: a server error or a lost concurrency race
+func retryable(c *gin.Context) bool {
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		return true
+	}
+
+	for _, err := range c.Errors {
+		if errors.Is(err.Err, repository.ErrConcurrencyConflict) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestHash fingerprints a request so a key cannot be replayed for another one
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
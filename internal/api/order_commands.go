@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type createOrderRequest struct {
+	CustomerID string `json:"customerId" binding:"required"`
+}
+
+type addItemRequest struct {
+	ProductID string  `json:"productId" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice float64 `json:"unitPrice" binding:"gte=0"`
+}
+
+type cancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type orderItemResponse struct {
+	ProductID uuid.UUID `json:"productId"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unitPrice"`
+}
+
+type orderResponse struct {
+	ID          uuid.UUID           `json:"id"`
+	CustomerID  uuid.UUID           `json:"customerId"`
+	Status      order.Status        `json:"status"`
+	Items       []orderItemResponse `json:"items"`
+	TotalAmount float64             `json:"totalAmount"`
+	Version     int                 `json:"version"`
+}
+
+// OrderCommandHandlers exposes the order commands over HTTP
+type OrderCommandHandlers struct {
+	commands *services.OrderCommandService
+}
+
+// NewOrderCommandHandlers creates the order command handlers
+func NewOrderCommandHandlers(commands *services.OrderCommandService) *OrderCommandHandlers {
+	return &OrderCommandHandlers{commands: commands}
+}
+
+// Register adds the order command routes to a router group
+func (h *OrderCommandHandlers) Register(r gin.IRouter) {
+	r.POST("/orders", h.createOrder)
+	r.POST("/orders/:id/items", h.addItem)
+	r.DELETE("/orders/:id/items/:productId", h.removeItem)
+	r.POST("/orders/:id/submit", h.submitOrder)
+	r.POST("/orders/:id/cancel", h.cancelOrder)
+}
+
+func (h *OrderCommandHandlers) createOrder(c *gin.Context) {
+	var req createOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		WriteProblem(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		WriteProblem(c, http.StatusBadRequest, "Invalid customer ID", err.Error())
+		return
+	}
+
+	o, err := h.commands.CreateOrder(c.Request.Context(), customerID)
+	if err != nil {
+		writeCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newOrderResponse(o))
+}
+
+func (h *OrderCommandHandlers) addItem(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req addItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		WriteProblem(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		WriteProblem(c, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	o, err := h.commands.AddItem(c.Request.Context(), orderID, productID, req.Quantity, req.UnitPrice)
+	if err != nil {
+		writeCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(o))
+}
+
+func (h *OrderCommandHandlers) removeItem(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	productID, ok := PathUUID(c, "productId")
+	if !ok {
+		return
+	}
+
+	o, err := h.commands.RemoveItem(c.Request.Context(), orderID, productID)
+	if err != nil {
+		writeCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(o))
+}
+
+func (h *OrderCommandHandlers) submitOrder(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	o, err := h.commands.SubmitOrder(c.Request.Context(), orderID)
+	if err != nil {
+		writeCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(o))
+}
+
+func (h *OrderCommandHandlers) cancelOrder(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted
+	var req cancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteProblem(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	o, err := h.commands.CancelOrder(c.Request.Context(), orderID, req.Reason)
+	if err != nil {
+		writeCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(o))
+}
+
+// writeCommandError maps domain and store errors to problem responses
+func writeCommandError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAggregateNotFound):
+		WriteProblem(c, http.StatusNotFound, "Order not found", err.Error())
+	case errors.Is(err, order.ErrItemNotFound):
+		WriteProblem(c, http.StatusNotFound, "Item not found", err.Error())
+	case errors.Is(err, order.ErrItemAlreadyAdded):
+		WriteProblem(c, http.StatusConflict, "Item already in order", err.Error())
+	case errors.Is(err, order.ErrOrderNotInDraftState),
+		errors.Is(err, order.ErrOrderCannotBeCancelled):
+		WriteProblem(c, http.StatusConflict, "Order state conflict", err.Error())
+	case errors.Is(err, order.ErrOrderHasNoItems):
+		WriteProblem(c, http.StatusUnprocessableEntity, "Order has no items", err.Error())
+	case errors.Is(err, repository.ErrConcurrencyConflict):
+		// Recorded so the idempotency middleware lets the request be retried
+		_ = c.Error(err)
+		WriteProblem(c, http.StatusConflict, "Concurrent modification", err.Error())
+	default:
+		log.Printf("command failed: %v", err)
+		WriteProblem(c, http.StatusInternalServerError, "Internal server error", "")
+	}
+}
+
+func newOrderResponse(o *order.Order) orderResponse {
+	items := make([]orderItemResponse, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, orderItemResponse{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	return orderResponse{
+		ID:          o.ID,
+		CustomerID:  o.CustomerID,
+		Status:      o.Status,
+		Items:       items,
+		TotalAmount: o.TotalAmount,
+		Version:     o.Version,
+	}
+}
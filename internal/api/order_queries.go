@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OrderLoader replays orders to a point in their history
+type OrderLoader interface {
+	LoadAt(ctx context.Context, id uuid.UUID, at repository.PointInTime) (*order.Order, error)
+}
+
+// OrderQueryHandlers exposes the order read model over HTTP
+type OrderQueryHandlers struct {
+	views  repository.OrderViewStore
+	events repository.EventStore
+	orders OrderLoader
+}
+
+// NewOrderQueryHandlers creates the order query handlers. Current state is read
+// from views; history and point-in-time reads replay events.
+func NewOrderQueryHandlers(views repository.OrderViewStore, events repository.EventStore, orders OrderLoader) *OrderQueryHandlers {
+	return &OrderQueryHandlers{
+		views:  views,
+		events: events,
+		orders: orders,
+	}
+}
+
+type orderChangeResponse struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
+
+type orderHistoryEntryResponse struct {
+	EventID       uuid.UUID              `json:"eventId"`
+	EventType     events.EventType       `json:"eventType"`
+	Version       int64                  `json:"version"`
+	OccurredAt    time.Time              `json:"occurredAt"`
+	UserID        string                 `json:"userId,omitempty"`
+	CorrelationID string                 `json:"correlationId,omitempty"`
+	CausationID   string                 `json:"causationId,omitempty"`
+	Details       interface{}            `json:"details"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Changes       []orderChangeResponse  `json:"changes"`
+}
+
+type orderHistoryResponse struct {
+	OrderID uuid.UUID                   `json:"orderId"`
+	Entries []orderHistoryEntryResponse `json:"entries"`
+}
+
+// Register adds the order query routes to a router group
+func (h *OrderQueryHandlers) Register(r gin.IRouter) {
+	r.GET("/orders", h.listOrders)
+	r.GET("/orders/:id", h.getOrder)
+	r.GET("/orders/:id/history", h.getOrderHistory)
+	r.GET("/customers/:id/orders", h.listCustomerOrders)
+}
+
+func (h *OrderQueryHandlers) getOrder(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	if asOf := c.Query("asOf"); asOf != "" {
+		h.getOrderAt(c, orderID, asOf)
+		return
+	}
+
+	view, err := h.views.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		writeQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// getOrderAt replays an order up to a version or an RFC 3339 time and
+// returns it in the same shape as the read model
+func (h *OrderQueryHandlers) getOrderAt(c *gin.Context, orderID uuid.UUID, asOf string) {
+	var at repository.PointInTime
+
+	if version, err := strconv.ParseInt(asOf, 10, 64); err == nil {
+		if version <= 0 {
+			WriteProblem(c, http.StatusBadRequest, "Invalid asOf", "version must be a positive integer")
+			return
+		}
+		at = repository.AtVersion(version)
+	} else if t, err := time.Parse(time.RFC3339, asOf); err == nil {
+		at = repository.AtTime(t)
+	} else {
+		WriteProblem(c, http.StatusBadRequest, "Invalid asOf", "asOf must be a version or an RFC 3339 time")
+		return
+	}
+
+	o, err := h.orders.LoadAt(c.Request.Context(), orderID, at)
+	if err != nil {
+		writeQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderView(o))
+}
+
+// getOrderHistory returns every event of an order with the changes it made
+func (h *OrderQueryHandlers) getOrderHistory(c *gin.Context) {
+	orderID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	stream, err := h.events.GetEventsByAggregateID(c.Request.Context(), order.AggregateType, orderID)
+	if err != nil {
+		writeQueryError(c, err)
+		return
+	}
+
+	history, err := order.History(stream)
+	if err != nil {
+		writeQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderHistoryResponse(orderID, history))
+}
+
+func (h *OrderQueryHandlers) listOrders(c *gin.Context) {
+	query, ok := parseOrderQuery(c)
+	if !ok {
+		return
+	}
+
+	h.writePage(c, query)
+}
+
+func (h *OrderQueryHandlers) listCustomerOrders(c *gin.Context) {
+	customerID, ok := PathUUID(c, "id")
+	if !ok {
+		return
+	}
+
+	query, ok := parseOrderQuery(c)
+	if !ok {
+		return
+	}
+	query.CustomerID = customerID
+
+	h.writePage(c, query)
+}
+
+func (h *OrderQueryHandlers) writePage(c *gin.Context, query repository.OrderQuery) {
+	page, err := h.views.ListOrders(c.Request.Context(), query)
+	if err != nil {
+		writeQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseOrderQuery reads the listing filters from the query string,
+// writing a 400 problem for invalid values
+func parseOrderQuery(c *gin.Context) (repository.OrderQuery, bool) {
+	query := repository.OrderQuery{
+		SortBy:     repository.OrderSortField(c.DefaultQuery("sort", string(repository.SortByCreatedAt))),
+		Descending: c.DefaultQuery("order", "desc") == "desc",
+		Cursor:     c.Query("cursor"),
+	}
+
+	switch query.SortBy {
+	case repository.SortByCreatedAt, repository.SortByUpdatedAt, repository.SortByTotalAmount:
+	default:
+		WriteProblem(c, http.StatusBadRequest, "Invalid sort", "sort must be createdAt, updatedAt or totalAmount")
+		return query, false
+	}
+
+	if direction := c.Query("order"); direction != "" && direction != "asc" && direction != "desc" {
+		WriteProblem(c, http.StatusBadRequest, "Invalid order", "order must be asc or desc")
+		return query, false
+	}
+
+	if status := c.Query("status"); status != "" {
+		switch order.Status(status) {
+		case order.StatusDraft, order.StatusSubmitted, order.StatusCancelled:
+			query.Status = status
+		default:
+			WriteProblem(c, http.StatusBadRequest, "Invalid status", "unknown order status "+status)
+			return query, false
+		}
+	}
+
+	var err error
+
+	if from := c.Query("from"); from != "" {
+		if query.From, err = time.Parse(time.RFC3339, from); err != nil {
+			WriteProblem(c, http.StatusBadRequest, "Invalid from", err.Error())
+			return query, false
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			WriteProblem(c, http.StatusBadRequest, "Invalid to", err.Error())
+			return query, false
+		}
+	}
+
+	if minTotal := c.Query("minTotal"); minTotal != "" {
+		value, err := strconv.ParseFloat(minTotal, 64)
+		if err != nil {
+			WriteProblem(c, http.StatusBadRequest, "Invalid minTotal", err.Error())
+			return query, false
+		}
+		query.MinTotal = &value
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit <= 0 {
+			WriteProblem(c, http.StatusBadRequest, "Invalid limit", "limit must be a positive integer")
+			return query, false
+		}
+	}
+
+	return query, true
+}
+
+// writeQueryError maps read model errors to problem responses
+func writeQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrOrderViewNotFound),
+		errors.Is(err, repository.ErrAggregateNotFound),
+		errors.Is(err, order.ErrNoHistory):
+		WriteProblem(c, http.StatusNotFound, "Order not found", err.Error())
+	case errors.Is(err, repository.ErrInvalidCursor):
+		WriteProblem(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+	default:
+		log.Printf("query failed: %v", err)
+		WriteProblem(c, http.StatusInternalServerError, "Internal server error", "")
+	}
+}
+
+// newOrderView converts an order aggregate to its read model representation
+func newOrderView(o *order.Order) repository.OrderView {
+	items := make([]repository.OrderViewItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, repository.OrderViewItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	return repository.OrderView{
+		ID:          o.ID,
+		CustomerID:  o.CustomerID,
+		Status:      string(o.Status),
+		Items:       items,
+		ItemCount:   len(items),
+		TotalAmount: o.TotalAmount,
+		Version:     int64(o.Version),
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+	}
+}
+
+func newOrderHistoryResponse(orderID uuid.UUID, history []order.HistoryEntry) orderHistoryResponse {
+	entries := make([]orderHistoryEntryResponse, 0, len(history))
+	for _, entry := range history {
+		changes := make([]orderChangeResponse, 0, len(entry.Changes))
+		for _, change := range entry.Changes {
+			changes = append(changes, orderChangeResponse{
+				Field: change.Field,
+				From:  orderChangeValue(change.From),
+				To:    orderChangeValue(change.To),
+			})
+		}
+
+		entries = append(entries, orderHistoryEntryResponse{
+			EventID:       entry.Event.EventID,
+			EventType:     entry.Event.EventType,
+			Version:       entry.Event.Sequence,
+			OccurredAt:    entry.Event.CreatedAt,
+			UserID:        entry.Event.UserID,
+			CorrelationID: entry.Event.CorrelationID,
+			CausationID:   entry.Event.CausationID,
+			Details:       entry.Payload,
+			Metadata:      entry.Event.Metadata,
+			Changes:       changes,
+		})
+	}
+
+	return orderHistoryResponse{OrderID: orderID, Entries: entries}
+}
+
+// orderChangeValue renders order items like the read model does
+func orderChangeValue(value interface{}) interface{} {
+	if item, ok := value.(order.OrderItem); ok {
+		return repository.OrderViewItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		}
+	}
+	return value
+}
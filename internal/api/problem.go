@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// WriteProblem aborts the request with an application/problem+json body
+func WriteProblem(c *gin.Context, status int, title, detail string) {
+	body, _ := json.Marshal(Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	})
+
+	c.Data(status, "application/problem+json", body)
+	c.Abort()
+}
+
+// PathUUID parses a UUID path parameter, writing a 400 problem if it is invalid
+func PathUUID(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		WriteProblem(c, http.StatusBadRequest, "Invalid "+name, err.Error())
+		return uuid.Nil, false
+	}
+	return id, true
+}
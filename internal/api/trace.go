@@ -0,0 +1,78 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderCorrelationID groups every request made for one user action
+	HeaderCorrelationID = "X-Correlation-ID"
+	// HeaderRequestID identifies a single request, which causes the events it records
+	HeaderRequestID = "X-Request-ID"
+	// HeaderUserID carries the user authenticated by the gateway
+	HeaderUserID = "X-User-ID"
+	// HeaderTraceParent is the W3C trace context header
+	HeaderTraceParent = "traceparent"
+
+	maxTraceHeaderLength = 255
+)
+
+// Tracing puts an events.Trace built from the request headers into the
+// request context, so every event a command records carries it. Missing
+// correlation and request IDs are generated, and both are echoed back.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := headerID(c, HeaderRequestID)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		// A request without a correlation ID starts a new flow
+		correlationID := headerID(c, HeaderCorrelationID)
+		if correlationID == "" {
+			correlationID = requestID
+		}
+
+		trace := events.Trace{
+			CorrelationID: correlationID,
+			CausationID:   requestID,
+			UserID:        headerID(c, HeaderUserID),
+			TraceID:       traceIDFromParent(c.GetHeader(HeaderTraceParent)),
+		}
+
+		c.Request = c.Request.WithContext(events.ContextWithTrace(c.Request.Context(), trace))
+		c.Header(HeaderCorrelationID, correlationID)
+		c.Header(HeaderRequestID, requestID)
+
+		c.Next()
+	}
+}
+
+// headerID returns a header value, ignoring values too long to store
+func headerID(c *gin.Context, name string) string {
+	value := strings.TrimSpace(c.GetHeader(name))
+	if len(value) > maxTraceHeaderLength {
+		return ""
+	}
+	return value
+}
+
+// traceIDFromParent extracts the trace ID from a traceparent header of the
+// form version-traceid-parentid-flags, returning "" if it is malformed
+func traceIDFromParent(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0123456789abcdef") != "" || strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+
+	return traceID
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationBus broadcasts keys changed by one replica to the others
+type InvalidationBus interface {
+	// Publish announces changed keys to the other replicas
+	Publish(ctx context.Context, keys []string) error
+	// Subscribe calls onKeys with keys changed by other replicas, and onReset
+	// whenever announcements may have been missed, until ctx is done
+	Subscribe(ctx context.Context, onKeys func(keys []string), onReset func()) error
+}
+
+// invalidation is the message published for changed keys
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// redisInvalidationBus implements InvalidationBus with Redis pub/sub
+type redisInvalidationBus struct {
+	client  *redis.Client
+	channel string
+	origin  string
+}
+
+// newRedisInvalidationBus creates a bus on channel. Each bus has its own
+// origin, so replicas ignore their own announcements.
+func newRedisInvalidationBus(client *redis.Client, channel string) *redisInvalidationBus {
+	return &redisInvalidationBus{
+		client:  client,
+		channel: channel,
+		origin:  uuid.New().String(),
+	}
+}
+
+// Publish implements InvalidationBus
+func (b *redisInvalidationBus) Publish(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	message, err := json.Marshal(invalidation{Origin: b.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	if err := b.client.Publish(ctx, b.channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe implements InvalidationBus. Every (re)subscription resets, since
+// announcements made while disconnected are lost.
+func (b *redisInvalidationBus) Subscribe(ctx context.Context, onKeys func(keys []string), onReset func()) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	for {
+		received, err := pubsub.Receive(ctx)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			fmt.Printf("Warning: cache invalidation subscription failed: %v\n", err)
+			onReset()
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch msg := received.(type) {
+
+		case *redis.Subscription:
+			onReset()
+
+		case *redis.Message:
+			var message invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				fmt.Printf("Warning: ignoring malformed cache invalidation: %v\n", err)
+				continue
+			}
+			if message.Origin != b.origin {
+				onKeys(message.Keys)
+			}
+		}
+	}
+}
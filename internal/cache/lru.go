@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry is a cached value or event list with its accounted size
+type lruEntry struct {
+	key     string
+	value   []byte
+	list    [][]byte
+	size    int64
+	expires time.Time
+}
+
+// lru is a size and count bounded least recently used cache with per entry expiry
+type lru struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	size       int64
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+// newLRU creates an LRU holding at most maxEntries entries and maxBytes bytes
+// of keys and values, each for at most ttl. Zero limits are unbounded.
+func newLRU(maxEntries int, maxBytes int64, ttl time.Duration) *lru {
+	return &lru{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the live entry of key and marks it as recently used
+func (l *lru) get(key string) (*lruEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		l.removeElement(element)
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+	return entry, true
+}
+
+// getValue returns the value cached under key
+func (l *lru) getValue(key string) ([]byte, bool) {
+	entry, ok := l.get(key)
+	if !ok || entry.list != nil {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// getList returns the event list cached under key
+func (l *lru) getList(key string) ([][]byte, bool) {
+	entry, ok := l.get(key)
+	if !ok || entry.list == nil {
+		return nil, false
+	}
+	return entry.list, true
+}
+
+// setValue caches a value under key
+func (l *lru) setValue(key string, value []byte) {
+	l.set(&lruEntry{key: key, value: value, size: int64(len(key) + len(value))})
+}
+
+// setList caches an event list under key
+func (l *lru) setList(key string, values [][]byte) {
+	size := int64(len(key))
+	for _, value := range values {
+		size += int64(len(value))
+	}
+	l.set(&lruEntry{key: key, list: values, size: size})
+}
+
+// appendList extends the event list cached under key if it holds exactly
+// expectedLen entries, and evicts it otherwise
+func (l *lru) appendList(key string, expectedLen int64, values [][]byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return
+	}
+
+	entry := element.Value.(*lruEntry)
+	if entry.list == nil || int64(len(entry.list)) != expectedLen {
+		l.removeElement(element)
+		return
+	}
+
+	// Copy so readers holding the old list are not affected
+	extended := &lruEntry{key: key, list: make([][]byte, 0, len(entry.list)+len(values)), size: entry.size}
+	extended.list = append(extended.list, entry.list...)
+	for _, value := range values {
+		extended.list = append(extended.list, value)
+		extended.size += int64(len(value))
+	}
+
+	l.setLocked(extended)
+}
+
+// set stores an entry and evicts the least recently used ones over the limits
+func (l *lru) set(entry *lruEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.setLocked(entry)
+}
+
+// setLocked stores an entry; the caller holds the lock. Entries larger than
+// the byte limit are not cached.
+func (l *lru) setLocked(entry *lruEntry) {
+	if element, ok := l.entries[entry.key]; ok {
+		l.removeElement(element)
+	}
+
+	if l.maxBytes > 0 && entry.size > l.maxBytes {
+		return
+	}
+
+	if l.ttl > 0 {
+		entry.expires = time.Now().Add(l.ttl)
+	}
+
+	l.entries[entry.key] = l.order.PushFront(entry)
+	l.size += entry.size
+
+	for l.overLimit() {
+		l.removeElement(l.order.Back())
+		localEvictions.Inc()
+	}
+}
+
+// overLimit reports whether the cache holds too many entries or bytes
+func (l *lru) overLimit() bool {
+	return (l.maxEntries > 0 && l.order.Len() > l.maxEntries) ||
+		(l.maxBytes > 0 && l.size > l.maxBytes)
+}
+
+// remove evicts the entry of key, if any
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.removeElement(element)
+	}
+}
+
+// purge evicts every entry
+func (l *lru) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order.Init()
+	l.entries = make(map[string]*list.Element)
+	l.size = 0
+}
+
+// removeElement unlinks an element; the caller holds the lock
+func (l *lru) removeElement(element *list.Element) {
+	entry := element.Value.(*lruEntry)
+	l.order.Remove(element)
+	delete(l.entries, entry.key)
+	l.size -= entry.size
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"github.com/HarshavardhanK/espm/pkg/telemetry"
+)
+
+// In-process cache metrics
+var (
+	localRequests = telemetry.DefaultRegistry.NewCounter(
+		"espm_local_cache_requests_total",
+		"Reads of the in-process cache by result: hit or miss.",
+		"result",
+	)
+	localEvictions = telemetry.DefaultRegistry.NewCounter(
+		"espm_local_cache_evictions_total",
+		"Entries evicted from the in-process cache to stay within its limits.",
+	)
+)
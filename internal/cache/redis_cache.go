@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // Common cache errors
@@ -19,6 +20,13 @@ type RedisCache interface {
 	Set(ctx context.Context, key string, value []byte) error
 	Delete(ctx context.Context, key string) error
 
+	// Versioned values, for entries that must never go back to an older version.
+	// SetVersioned stores value unless a newer version of key was set or
+	// invalidated, and reports whether it was written. InvalidateVersioned drops
+	// key if it is older than version and rejects later writes of older versions.
+	SetVersioned(ctx context.Context, key string, version int64, value []byte) (bool, error)
+	InvalidateVersioned(ctx context.Context, key string, version int64) error
+
 	// Batch operations
 	BatchGet(ctx context.Context, keys []string) (map[string][]byte, error)
 	BatchSet(ctx context.Context, pairs map[string][]byte) error
@@ -29,6 +37,25 @@ type RedisCache interface {
 	SetEventStream(ctx context.Context, aggregateType, aggregateID string, value []byte) error
 	BatchSetEventStreams(ctx context.Context, streams map[string]map[string][]byte) error
 
+	// Event list operations, caching a stream as one entry per event so it can
+	// grow without being rewritten. GetEventList also reports whether the list
+	// is fresh; lists past their TTL are kept for the stale window and served
+	// as not fresh.
+	GetEventList(ctx context.Context, aggregateType, aggregateID string) ([][]byte, bool, error)
+	SetEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) error
+	// FillEventList caches values read from the store as the event list unless
+	// the cached list is already longer, as it is when events were appended
+	// after the read. It reports whether the list was written.
+	FillEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) (bool, error)
+	// AppendEventList appends values to a cached list holding exactly expectedLen
+	// entries, or starts the list when expectedLen is 0. A list of any other
+	// length is dropped. It reports whether the values were appended.
+	AppendEventList(ctx context.Context, aggregateType, aggregateID string, expectedLen int64, values [][]byte) (bool, error)
+
+	// Locks, held by the owner of token until released or expired
+	TryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key, token string) error
+
 	// Health and maintenance
 	HealthCheck(ctx context.Context) error
 	Close() error
@@ -12,11 +12,13 @@ import (
 
 // Simple wrapper around Redis client
 type redisCache struct {
-	client *redis.Client
-	ttl    time.Duration
+	client   *redis.Client
+	ttl      time.Duration
+	staleTTL time.Duration
 }
 
-// Creates a new Redis client with the given config
+// Creates a new Redis client with the given config, fronted by an in-process
+// cache when local limits are configured
 func NewRedisCache(cfg config.RedisConfig) (RedisCache, error) {
 
 	// Enhanced connection pooling configuration
@@ -45,10 +47,18 @@ func NewRedisCache(cfg config.RedisConfig) (RedisCache, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &redisCache{
-		client: client,
-		ttl:    cfg.TTL,
-	}, nil
+	remote := &redisCache{
+		client:   client,
+		ttl:      cfg.TTL,
+		staleTTL: cfg.StaleTTL,
+	}
+
+	if cfg.LocalMaxEntries <= 0 && cfg.LocalMaxBytes <= 0 {
+		return remote, nil
+	}
+
+	// Keep hot keys in process memory as well
+	return NewTieredCache(remote, newRedisInvalidationBus(client, cfg.InvalidationChannel), cfg), nil
 }
 
 // Get a value from Redis
@@ -83,6 +93,78 @@ func (r *redisCache) Set(ctx context.Context, key string, value []byte) error {
 	return nil
 }
 
+// versionKey returns the key holding the version of a versioned value
+func versionKey(key string) string {
+	return key + ":version"
+}
+
+// setVersionedScript stores ARGV[3] in KEYS[1] and version ARGV[1] in KEYS[2]
+// unless KEYS[2] holds a newer version, expiring both after ARGV[2]
+// milliseconds if positive
+var setVersionedScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[2]))
+if current and current > tonumber(ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[2])
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1], ARGV[3])
+	redis.call('SET', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// Store a value unless a newer version of it was stored or invalidated
+func (r *redisCache) SetVersioned(ctx context.Context, key string, version int64, value []byte) (bool, error) {
+	if key == "" {
+		return false, ErrInvalidKey
+	}
+
+	keys := []string{key, versionKey(key)}
+
+	written, err := setVersionedScript.Run(ctx, r.client, keys, version, r.ttl.Milliseconds(), value).Int()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to set versioned cache: %w", err)
+	}
+
+	return written == 1, nil
+}
+
+// invalidateVersionedScript deletes KEYS[1] and records version ARGV[1] in
+// KEYS[2] unless KEYS[2] already holds that version or a newer one, expiring
+// the record after ARGV[2] milliseconds if positive
+var invalidateVersionedScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[2]))
+if current and current >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// Drop a value older than version and keep older versions from being stored again
+func (r *redisCache) InvalidateVersioned(ctx context.Context, key string, version int64) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	keys := []string{key, versionKey(key)}
+
+	if err := invalidateVersionedScript.Run(ctx, r.client, keys, version, r.ttl.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate versioned cache: %w", err)
+	}
+
+	return nil
+}
+
 // BatchSet stores multiple key-value pairs in Redis
 func (r *redisCache) BatchSet(ctx context.Context, pairs map[string][]byte) error {
 	if len(pairs) == 0 {
@@ -194,9 +276,7 @@ func (r *redisCache) GetEventStream(ctx context.Context, aggregateType, aggregat
 		return nil, ErrInvalidKey
 	}
 
-	key := fmt.Sprintf("events:%s:%s", aggregateType, aggregateID)
-
-	return r.Get(ctx, key)
+	return r.Get(ctx, eventStreamKey(aggregateType, aggregateID))
 }
 
 // Store event stream for an aggregate
@@ -206,9 +286,7 @@ func (r *redisCache) SetEventStream(ctx context.Context, aggregateType, aggregat
 		return ErrInvalidKey
 	}
 
-	key := fmt.Sprintf("events:%s:%s", aggregateType, aggregateID)
-
-	return r.Set(ctx, key, value)
+	return r.Set(ctx, eventStreamKey(aggregateType, aggregateID), value)
 }
 
 // BatchSetEventStreams stores multiple event streams
@@ -223,14 +301,229 @@ func (r *redisCache) BatchSetEventStreams(ctx context.Context, streams map[strin
 	for aggregateType, typeStreams := range streams {
 
 		for aggregateID, value := range typeStreams {
-			key := fmt.Sprintf("events:%s:%s", aggregateType, aggregateID)
-			pairs[key] = value
+			pairs[eventStreamKey(aggregateType, aggregateID)] = value
 		}
 	}
 
 	return r.BatchSet(ctx, pairs)
 }
 
+// eventStreamKey returns the key of the cached event stream of an aggregate
+func eventStreamKey(aggregateType, aggregateID string) string {
+	return fmt.Sprintf("events:%s:%s", aggregateType, aggregateID)
+}
+
+// eventListKey returns the key of the cached event list of an aggregate
+func eventListKey(aggregateType, aggregateID string) string {
+	return fmt.Sprintf("stream:%s:%s", aggregateType, aggregateID)
+}
+
+// Get the cached event list of an aggregate and whether it is fresh
+func (r *redisCache) GetEventList(ctx context.Context, aggregateType, aggregateID string) ([][]byte, bool, error) {
+
+	if aggregateType == "" || aggregateID == "" {
+		return nil, false, ErrInvalidKey
+	}
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	pipe := r.client.Pipeline()
+	rangeCmd := pipe.LRange(ctx, key, 0, -1)
+	ttlCmd := pipe.PTTL(ctx, key)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to get event list from cache: %w", err)
+	}
+
+	values := rangeCmd.Val()
+
+	// Empty streams are never cached, so an empty list is a missing key
+	if len(values) == 0 {
+		return nil, false, ErrCacheMiss
+	}
+
+	result := make([][]byte, len(values))
+	for i, value := range values {
+		result[i] = []byte(value)
+	}
+
+	// Lists expire staleTTL after their TTL, so a shorter remaining
+	// lifetime means the TTL has passed. Negative values mean no expiry.
+	remaining := ttlCmd.Val()
+	fresh := r.staleTTL <= 0 || remaining < 0 || remaining > r.staleTTL
+
+	return result, fresh, nil
+}
+
+// listTTL is the lifetime of a cached event list, including its stale window
+func (r *redisCache) listTTL() time.Duration {
+
+	if r.ttl <= 0 {
+		return 0
+	}
+
+	return r.ttl + r.staleTTL
+}
+
+// Replace the cached event list of an aggregate
+func (r *redisCache) SetEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) error {
+
+	if aggregateType == "" || aggregateID == "" {
+		return ErrInvalidKey
+	}
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+
+		pipe.Del(ctx, key)
+
+		if len(values) == 0 {
+			return nil
+		}
+
+		pipe.RPush(ctx, key, listArgs(values)...)
+
+		if ttl := r.listTTL(); ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to set event list in cache: %w", err)
+	}
+
+	return nil
+}
+
+// fillEventListScript replaces the list in KEYS[1] with ARGV[2..] unless it
+// holds more entries, expiring it after ARGV[1] milliseconds if positive
+var fillEventListScript = redis.NewScript(`
+if redis.call('LLEN', KEYS[1]) > #ARGV - 1 then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('RPUSH', KEYS[1], unpack(ARGV, 2))
+if tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// Cache a stream read from the store unless the cached list has grown past it
+func (r *redisCache) FillEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) (bool, error) {
+
+	if aggregateType == "" || aggregateID == "" {
+		return false, ErrInvalidKey
+	}
+
+	// Empty streams are never cached
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	args := append([]interface{}{r.listTTL().Milliseconds()}, listArgs(values)...)
+
+	filled, err := fillEventListScript.Run(ctx, r.client, []string{eventListKey(aggregateType, aggregateID)}, args...).Int()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to fill event list in cache: %w", err)
+	}
+
+	return filled == 1, nil
+}
+
+// appendEventListScript appends ARGV[3..] to the list in KEYS[1] if it holds
+// exactly ARGV[1] entries, expiring it after ARGV[2] milliseconds if positive.
+// A list of any other length missed events and is deleted.
+var appendEventListScript = redis.NewScript(`
+local length = redis.call('LLEN', KEYS[1])
+if length ~= tonumber(ARGV[1]) then
+	if length > 0 then
+		redis.call('DEL', KEYS[1])
+	end
+	return 0
+end
+redis.call('RPUSH', KEYS[1], unpack(ARGV, 3))
+if tonumber(ARGV[2]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// Append events to the cached event list of an aggregate
+func (r *redisCache) AppendEventList(ctx context.Context, aggregateType, aggregateID string, expectedLen int64, values [][]byte) (bool, error) {
+
+	if aggregateType == "" || aggregateID == "" {
+		return false, ErrInvalidKey
+	}
+
+	if len(values) == 0 {
+		return true, nil
+	}
+
+	args := append([]interface{}{expectedLen, r.listTTL().Milliseconds()}, listArgs(values)...)
+
+	appended, err := appendEventListScript.Run(ctx, r.client, []string{eventListKey(aggregateType, aggregateID)}, args...).Int()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to append to event list in cache: %w", err)
+	}
+
+	return appended == 1, nil
+}
+
+// listArgs converts list values to command arguments
+func listArgs(values [][]byte) []interface{} {
+
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
+
+// Take a lock unless another token holds it
+func (r *redisCache) TryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+
+	if key == "" {
+		return false, ErrInvalidKey
+	}
+
+	locked, err := r.client.SetNX(ctx, key, token, ttl).Result()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to take lock: %w", err)
+	}
+
+	return locked, nil
+}
+
+// unlockScript deletes the lock in KEYS[1] only if ARGV[1] still holds it
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Release a lock held by token
+func (r *redisCache) Unlock(ctx context.Context, key, token string) error {
+
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	if err := unlockScript.Run(ctx, r.client, []string{key}, token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return nil
+}
+
 // Check if Redis is responding
 func (r *redisCache) HealthCheck(ctx context.Context) error {
 
@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+)
+
+// TieredCache is a bounded in-process LRU in front of another RedisCache.
+// Reads are served from process memory when possible; writes go to the remote
+// cache first and are announced on the invalidation bus, so other replicas
+// evict their local copies.
+type TieredCache struct {
+	remote RedisCache
+	local  *lru
+	bus    InvalidationBus
+	stop   context.CancelFunc
+}
+
+// NewTieredCache puts a local LRU bounded by the Local* settings of cfg in
+// front of remote and starts listening for invalidations on bus
+func NewTieredCache(remote RedisCache, bus InvalidationBus, cfg config.RedisConfig) *TieredCache {
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	c := &TieredCache{
+		remote: remote,
+		local:  newLRU(cfg.LocalMaxEntries, cfg.LocalMaxBytes, cfg.LocalTTL),
+		bus:    bus,
+		stop:   stop,
+	}
+
+	go func() {
+		if err := bus.Subscribe(ctx, c.evict, c.local.purge); err != nil {
+			fmt.Printf("Warning: cache invalidations stopped: %v\n", err)
+		}
+	}()
+
+	return c
+}
+
+// Get a value, from process memory if possible
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+
+	if value, ok := c.local.getValue(key); ok {
+		localRequests.Inc("hit")
+		return value, nil
+	}
+
+	localRequests.Inc("miss")
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.local.setValue(key, value)
+	return value, nil
+}
+
+// Set a value in both tiers
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte) error {
+
+	if err := c.remote.Set(ctx, key, value); err != nil {
+		c.local.remove(key)
+		return err
+	}
+
+	c.local.setValue(key, value)
+	c.publish(ctx, key)
+	return nil
+}
+
+// Delete a value from both tiers
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+
+	c.local.remove(key)
+
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	c.publish(ctx, key)
+	return nil
+}
+
+// SetVersioned sets a versioned value in both tiers unless the remote cache
+// holds a newer version
+func (c *TieredCache) SetVersioned(ctx context.Context, key string, version int64, value []byte) (bool, error) {
+
+	written, err := c.remote.SetVersioned(ctx, key, version, value)
+
+	if err != nil || !written {
+		c.local.remove(key)
+		return false, err
+	}
+
+	c.local.setValue(key, value)
+	c.publish(ctx, key)
+	return true, nil
+}
+
+// InvalidateVersioned drops a versioned value older than version from both tiers
+func (c *TieredCache) InvalidateVersioned(ctx context.Context, key string, version int64) error {
+
+	c.local.remove(key)
+
+	if err := c.remote.InvalidateVersioned(ctx, key, version); err != nil {
+		return err
+	}
+
+	c.publish(ctx, key)
+	return nil
+}
+
+// BatchGet retrieves values, asking the remote cache only for local misses
+func (c *TieredCache) BatchGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+
+	result := make(map[string][]byte, len(keys))
+	missing := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if value, ok := c.local.getValue(key); ok {
+			localRequests.Inc("hit")
+			result[key] = value
+			continue
+		}
+		localRequests.Inc("miss")
+		missing = append(missing, key)
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	fetched, err := c.remote.BatchGet(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range fetched {
+		c.local.setValue(key, value)
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// BatchSet stores values in both tiers
+func (c *TieredCache) BatchSet(ctx context.Context, pairs map[string][]byte) error {
+
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	if err := c.remote.BatchSet(ctx, pairs); err != nil {
+		for _, key := range keys {
+			c.local.remove(key)
+		}
+		return err
+	}
+
+	for key, value := range pairs {
+		c.local.setValue(key, value)
+	}
+
+	c.publish(ctx, keys...)
+	return nil
+}
+
+// BatchDelete removes values from both tiers
+func (c *TieredCache) BatchDelete(ctx context.Context, keys []string) error {
+
+	for _, key := range keys {
+		c.local.remove(key)
+	}
+
+	if err := c.remote.BatchDelete(ctx, keys); err != nil {
+		return err
+	}
+
+	c.publish(ctx, keys...)
+	return nil
+}
+
+// GetEventStream gets the event stream of an aggregate
+func (c *TieredCache) GetEventStream(ctx context.Context, aggregateType, aggregateID string) ([]byte, error) {
+
+	if aggregateType == "" || aggregateID == "" {
+		return nil, ErrInvalidKey
+	}
+
+	return c.Get(ctx, eventStreamKey(aggregateType, aggregateID))
+}
+
+// SetEventStream stores the event stream of an aggregate
+func (c *TieredCache) SetEventStream(ctx context.Context, aggregateType, aggregateID string, value []byte) error {
+
+	if aggregateType == "" || aggregateID == "" {
+		return ErrInvalidKey
+	}
+
+	return c.Set(ctx, eventStreamKey(aggregateType, aggregateID), value)
+}
+
+// BatchSetEventStreams stores multiple event streams
+func (c *TieredCache) BatchSetEventStreams(ctx context.Context, streams map[string]map[string][]byte) error {
+
+	pairs := make(map[string][]byte)
+
+	for aggregateType, typeStreams := range streams {
+		for aggregateID, value := range typeStreams {
+			pairs[eventStreamKey(aggregateType, aggregateID)] = value
+		}
+	}
+
+	return c.BatchSet(ctx, pairs)
+}
+
+// GetEventList gets the event list of an aggregate. Local copies are always
+// fresh; stale remote lists are returned but not kept in process memory.
+func (c *TieredCache) GetEventList(ctx context.Context, aggregateType, aggregateID string) ([][]byte, bool, error) {
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	if values, ok := c.local.getList(key); ok {
+		localRequests.Inc("hit")
+		return values, true, nil
+	}
+
+	localRequests.Inc("miss")
+
+	values, fresh, err := c.remote.GetEventList(ctx, aggregateType, aggregateID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if fresh {
+		c.local.setList(key, values)
+	}
+
+	return values, fresh, nil
+}
+
+// SetEventList replaces the event list of an aggregate in both tiers
+func (c *TieredCache) SetEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) error {
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	if err := c.remote.SetEventList(ctx, aggregateType, aggregateID, values); err != nil {
+		c.local.remove(key)
+		return err
+	}
+
+	if len(values) == 0 {
+		c.local.remove(key)
+	} else {
+		c.local.setList(key, values)
+	}
+
+	c.publish(ctx, key)
+	return nil
+}
+
+// FillEventList caches a stream read from the store in both tiers, unless the
+// remote list has grown past it
+func (c *TieredCache) FillEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) (bool, error) {
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	filled, err := c.remote.FillEventList(ctx, aggregateType, aggregateID, values)
+
+	if err != nil || !filled {
+		c.local.remove(key)
+		return false, err
+	}
+
+	c.local.setList(key, values)
+
+	c.publish(ctx, key)
+	return true, nil
+}
+
+// AppendEventList appends to the event list of an aggregate in both tiers
+func (c *TieredCache) AppendEventList(ctx context.Context, aggregateType, aggregateID string, expectedLen int64, values [][]byte) (bool, error) {
+
+	key := eventListKey(aggregateType, aggregateID)
+
+	appended, err := c.remote.AppendEventList(ctx, aggregateType, aggregateID, expectedLen, values)
+
+	switch {
+	case err != nil || !appended:
+		c.local.remove(key)
+	case expectedLen == 0:
+		c.local.setList(key, values)
+	default:
+		c.local.appendList(key, expectedLen, values)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	c.publish(ctx, key)
+	return appended, nil
+}
+
+// TryLock takes a lock in the remote cache, which all replicas share
+func (c *TieredCache) TryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return c.remote.TryLock(ctx, key, token, ttl)
+}
+
+// Unlock releases a lock in the remote cache
+func (c *TieredCache) Unlock(ctx context.Context, key, token string) error {
+	return c.remote.Unlock(ctx, key, token)
+}
+
+// HealthCheck checks the remote cache
+func (c *TieredCache) HealthCheck(ctx context.Context) error {
+	return c.remote.HealthCheck(ctx)
+}
+
+// Close stops listening for invalidations and closes the remote cache
+func (c *TieredCache) Close() error {
+	c.stop()
+	return c.remote.Close()
+}
+
+// evict drops keys changed by another replica
+func (c *TieredCache) evict(keys []string) {
+	for _, key := range keys {
+		c.local.remove(key)
+	}
+}
+
+// publish announces changed keys; a failed announcement is only logged, as
+// other replicas' copies still expire after the local TTL
+func (c *TieredCache) publish(ctx context.Context, keys ...string) {
+	if err := c.bus.Publish(ctx, keys); err != nil {
+		fmt.Printf("Warning: failed to announce cache invalidation: %v\n", err)
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings shared by the ESPM services
+type Config struct {
+	Server    ServerConfig   `yaml:"server"`
+	Database  DatabaseConfig `yaml:"database"`
+	Redis     RedisConfig    `yaml:"redis"`
+	Outbox    OutboxConfig   `yaml:"outbox"`
+	Snapshots SnapshotConfig `yaml:"snapshots"`
+	Events    EventsConfig   `yaml:"events"`
+}
+
+// ServerConfig holds HTTP server settings
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	MetricsPort  int           `yaml:"metrics_port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// IdempotencyWindow is how long Idempotency-Key responses are kept for replay
+	IdempotencyWindow time.Duration `yaml:"idempotency_window"`
+}
+
+// DatabaseConfig holds PostgreSQL connection settings
+type DatabaseConfig struct {
+	Host               string        `yaml:"host"`
+	Port               int           `yaml:"port"`
+	User               string        `yaml:"user"`
+	Password           string        `yaml:"password"`
+	Name               string        `yaml:"name"`
+	SSLMode            string        `yaml:"ssl_mode"`
+	MaxConnections     int           `yaml:"max_connections"`
+	MaxIdleConnections int           `yaml:"max_idle_connections"`
+	ConnectionLifetime time.Duration `yaml:"connection_lifetime"`
+}
+
+// DSN returns the lib/pq connection string for the database
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		c.User, c.Password, c.Host, c.Port, c.Name, c.SSLMode,
+	)
+}
+
+// DefaultConfig returns configuration suitable for local development
+func DefaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:              8080,
+			MetricsPort:       9090,
+			ReadTimeout:       time.Second * 30,
+			WriteTimeout:      time.Second * 30,
+			IdleTimeout:       time.Second * 120,
+			IdempotencyWindow: time.Hour * 24,
+		},
+		Database: DatabaseConfig{
+			Host:               "localhost",
+			Port:               5432,
+			User:               "espm",
+			Password:           "espm123",
+			Name:               "espm",
+			SSLMode:            "disable",
+			MaxConnections:     50,
+			MaxIdleConnections: 10,
+			ConnectionLifetime: time.Hour,
+		},
+		Redis:     DefaultRedisConfig(),
+		Outbox:    DefaultOutboxConfig(),
+		Snapshots: DefaultSnapshotConfig(),
+		Events:    DefaultEventsConfig(),
+	}
+}
+
+// Load reads a YAML config file on top of the defaults.
+// An empty path returns the defaults.
+func Load(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if err := cfg.Snapshots.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	if err := cfg.Events.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+)
+
+// EventsConfig holds event storage settings
+type EventsConfig struct {
+	// PayloadFormat is the encoding new event payloads are stored in: "json" or "msgpack".
+	// Stored events keep the format they were written with.
+	PayloadFormat string `yaml:"payload_format"`
+}
+
+// DefaultEventsConfig returns default event storage configuration
+func DefaultEventsConfig() EventsConfig {
+	return EventsConfig{
+		PayloadFormat: string(events.FormatJSON),
+	}
+}
+
+// Validate reports settings events cannot be stored with
+func (c EventsConfig) Validate() error {
+	if _, err := events.SerializerFor(events.Format(c.PayloadFormat)); err != nil {
+		return fmt.Errorf("invalid event payload format: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+)
+
+// OutboxConfig holds event publisher settings
+type OutboxConfig struct {
+	// Sink selects the publisher sink: "file" or "log"
+	Sink     string `yaml:"sink"`
+	FilePath string `yaml:"file_path"`
+	Topic    string `yaml:"topic"`
+	// Format is the message encoding: "json" or "msgpack"
+	Format string `yaml:"format"`
+	// BatchSize is the number of aggregates claimed per round
+	BatchSize        int           `yaml:"batch_size"`
+	LeaseDuration    time.Duration `yaml:"lease_duration"`
+	PollInterval     time.Duration `yaml:"poll_interval"`
+	RetryInterval    time.Duration `yaml:"retry_interval"`
+	MaxRetryInterval time.Duration `yaml:"max_retry_interval"`
+	// Retention is how long published messages are kept before they are purged
+	Retention time.Duration `yaml:"retention"`
+}
+
+// DefaultOutboxConfig returns default event publisher configuration
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		Sink:             "file",
+		FilePath:         "events.jsonl",
+		Topic:            "events",
+		Format:           "json",
+		BatchSize:        100,
+		LeaseDuration:    time.Second * 30,
+		PollInterval:     time.Second,
+		RetryInterval:    time.Second,
+		MaxRetryInterval: time.Minute * 5,
+		Retention:        time.Hour * 24,
+	}
+}
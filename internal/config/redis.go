@@ -6,17 +6,35 @@ import (
 
 // RedisConfig holds Redis connection settings
 type RedisConfig struct {
-	Host         string
-	Port         int
-	Password     string
-	DB           int
-	PoolSize     int
-	MinIdleConns int
-	DialTimeout  time.Duration
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	MaxRetries   int
-	TTL          time.Duration
+	Host         string        `yaml:"host"`
+	Port         int           `yaml:"port"`
+	Password     string        `yaml:"password"`
+	DB           int           `yaml:"db"`
+	PoolSize     int           `yaml:"pool_size"`
+	MinIdleConns int           `yaml:"min_idle_conns"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	MaxRetries   int           `yaml:"max_retries"`
+	TTL          time.Duration `yaml:"ttl"`
+	// StaleTTL keeps cached streams this long past TTL, served while one caller reloads them
+	StaleTTL time.Duration `yaml:"stale_ttl"`
+	// LockTTL is the lifetime of the lock that lets one replica reload an
+	// expired stream while others wait; 0 disables locking
+	LockTTL time.Duration `yaml:"lock_ttl"`
+	// LockWait is how long a replica without the lock waits for the stream to be cached
+	LockWait time.Duration `yaml:"lock_wait"`
+	// LocalMaxEntries bounds the number of entries kept in process memory in
+	// front of Redis; the local cache is off when both local limits are 0
+	LocalMaxEntries int `yaml:"local_max_entries"`
+	// LocalMaxBytes bounds the size of the keys and values kept in process memory
+	LocalMaxBytes int64 `yaml:"local_max_bytes"`
+	// LocalTTL bounds how long an entry is served from process memory
+	LocalTTL time.Duration `yaml:"local_ttl"`
+	// InvalidationChannel is the pub/sub channel replicas announce changed keys on
+	InvalidationChannel string `yaml:"invalidation_channel"`
+	// Format is the encoding of cached event streams: "json" or "msgpack"
+	Format string `yaml:"format"`
 }
 
 // DefaultRedisConfig returns default Redis configuration
@@ -33,5 +51,13 @@ func DefaultRedisConfig() RedisConfig {
 		WriteTimeout: time.Second * 3,
 		MaxRetries:   3,
 		TTL:          time.Hour * 24,
+		LockWait:     time.Millisecond * 500,
+		Format:       "json",
+
+		// In-process cache in front of Redis
+		LocalMaxEntries:     10000,
+		LocalMaxBytes:       64 << 20,
+		LocalTTL:            time.Minute,
+		InvalidationChannel: "espm:cache:invalidations",
 	}
 }
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HarshavardhanK/espm/pkg/compress"
+)
+
+// SnapshotConfig holds automatic snapshotting settings
+type SnapshotConfig struct {
+	// Workers is the number of background snapshot writers, 0 snapshots inline
+	Workers int `yaml:"workers"`
+	// QueueSize bounds the pending snapshots per worker; saves beyond it skip snapshotting
+	QueueSize int `yaml:"queue_size"`
+	// Policies holds the snapshot policy of each aggregate type
+	Policies map[string]SnapshotPolicyConfig `yaml:"policies"`
+	// Retain is the number of snapshots kept per aggregate, so loads can fall back
+	// to an older snapshot when the newest cannot be decoded
+	Retain int `yaml:"retain"`
+	// PruneInterval is how often snapshots beyond Retain are deleted
+	PruneInterval time.Duration `yaml:"prune_interval"`
+	// Compression is the algorithm large snapshots are stored with: none, gzip or zstd
+	Compression string `yaml:"compression"`
+	// CompressAbove is the encoded size in bytes from which snapshots are compressed
+	CompressAbove int `yaml:"compress_above"`
+	// RegenerateOutdated rebuilds snapshots of outdated schema versions in the background on startup
+	RegenerateOutdated bool `yaml:"regenerate_outdated"`
+	// RegenerateBatchSize is the number of outdated snapshots read per batch
+	RegenerateBatchSize int `yaml:"regenerate_batch_size"`
+}
+
+// SnapshotPolicyConfig describes when an aggregate type is snapshotted.
+// A snapshot is taken when any of the set rules matches; zero values are unset.
+type SnapshotPolicyConfig struct {
+	// EveryEvents snapshots once this many events were written since the last snapshot
+	EveryEvents int64 `yaml:"every_events"`
+	// ReplayLongerThan snapshots when loading the aggregate took at least this long
+	ReplayLongerThan time.Duration `yaml:"replay_longer_than"`
+	// OnEventTypes snapshots after a save that wrote one of these event types
+	OnEventTypes []string `yaml:"on_event_types"`
+	// StateSizeAbove snapshots when the encoded state reaches this many bytes
+	StateSizeAbove int `yaml:"state_size_above"`
+	// EveryInterval snapshots when the last snapshot is older than this
+	EveryInterval time.Duration `yaml:"every_interval"`
+}
+
+// DefaultSnapshotConfig returns default snapshot configuration
+func DefaultSnapshotConfig() SnapshotConfig {
+	return SnapshotConfig{
+		Workers:             4,
+		QueueSize:           256,
+		Retain:              3,
+		PruneInterval:       time.Minute * 10,
+		Compression:         "none",
+		CompressAbove:       4096,
+		RegenerateBatchSize: 100,
+		Policies: map[string]SnapshotPolicyConfig{
+			"Order": {
+				EveryEvents:      50,
+				ReplayLongerThan: time.Millisecond * 100,
+				OnEventTypes:     []string{"OrderSubmitted"},
+			},
+		},
+	}
+}
+
+// Validate reports settings the snapshot store cannot run with
+func (c SnapshotConfig) Validate() error {
+	if _, err := compress.CodecFor(compress.Algorithm(c.Compression)); err != nil {
+		return fmt.Errorf("invalid snapshot compression: %w", err)
+	}
+
+	return nil
+}
@@ -9,9 +9,18 @@ var (
 	// ErrItemNotFound is returned when trying to remove a non-existent item
 	ErrItemNotFound = errors.New("item not found in order")
 
+	// ErrItemAlreadyAdded is returned when adding a product the order already holds
+	ErrItemAlreadyAdded = errors.New("item already in order")
+
 	// ErrOrderHasNoItems is returned when trying to submit an order without items
 	ErrOrderHasNoItems = errors.New("order has no items")
 
 	// ErrOrderCannotBeCancelled is returned when trying to cancel an order in an invalid state
 	ErrOrderCannotBeCancelled = errors.New("order cannot be cancelled in current state")
+
+	// ErrNoHistory is returned when an order is loaded from an empty event stream
+	ErrNoHistory = errors.New("order has no events")
+
+	// ErrUnknownEventType is returned when applying an event the order does not handle
+	ErrUnknownEventType = errors.New("unknown order event type")
 )
@@ -0,0 +1,23 @@
+package order
+
+import "github.com/HarshavardhanK/espm/internal/events"
+
+// eventTypes is the registry of order event payloads, shared read-only
+var eventTypes = newEventTypes()
+
+// EventTypes returns the registry mapping order event types to their payload structs
+func EventTypes() *events.TypeRegistry {
+	return eventTypes
+}
+
+func newEventTypes() *events.TypeRegistry {
+	r := events.NewTypeRegistry()
+
+	r.MustRegister(events.OrderCreatedEventType, eventVersion, events.OrderCreatedEvent{})
+	r.MustRegister(events.OrderItemAddedEventType, eventVersion, events.OrderItemAddedEvent{})
+	r.MustRegister(events.OrderItemRemovedEventType, eventVersion, events.OrderItemRemovedEvent{})
+	r.MustRegister(events.OrderSubmittedEventType, eventVersion, events.OrderSubmittedEvent{})
+	r.MustRegister(events.OrderCancelledEventType, eventVersion, events.OrderCancelledEvent{})
+
+	return r
+}
@@ -0,0 +1,128 @@
+package order
+
+import (
+	"github.com/HarshavardhanK/espm/internal/events"
+)
+
+// Order fields reported in a history diff
+const (
+	FieldCustomerID  = "customerId"
+	FieldStatus      = "status"
+	FieldItems       = "items"
+	FieldTotalAmount = "totalAmount"
+)
+
+// FieldChange is a change to one field of an order. For items, an added item
+// has a nil From and a removed item a nil To.
+type FieldChange struct {
+	Field string
+	From  interface{}
+	To    interface{}
+}
+
+// HistoryEntry is an event together with the changes it made to the order
+type HistoryEntry struct {
+	Event events.Event
+	// Payload is the decoded event payload, e.g. an OrderCancelledEvent
+	Payload interface{}
+	Changes []FieldChange
+}
+
+// History folds an order's events in order and reports the changes each made
+func History(history []events.Event) ([]HistoryEntry, error) {
+
+	if len(history) == 0 {
+		return nil, ErrNoHistory
+	}
+
+	o := Empty(history[0].AggregateID)
+	entries := make([]HistoryEntry, 0, len(history))
+
+	for _, event := range history {
+
+		before := o.clone()
+
+		if err := o.Apply(event); err != nil {
+			return nil, err
+		}
+
+		payload, err := eventTypes.Decode(event)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, HistoryEntry{
+
+			Event:   event,
+			Payload: payload,
+			Changes: Diff(before, o),
+		})
+	}
+
+	return entries, nil
+}
+
+// Diff returns the field changes between two states of an order.
+// Timestamps and the version change with every event and are left out.
+func Diff(before, after *Order) []FieldChange {
+
+	var changes []FieldChange
+
+	if before.CustomerID != after.CustomerID {
+		changes = append(changes, FieldChange{Field: FieldCustomerID, From: before.CustomerID, To: after.CustomerID})
+	}
+
+	if before.Status != after.Status {
+		changes = append(changes, FieldChange{Field: FieldStatus, From: before.Status, To: after.Status})
+	}
+
+	// Items are compared as multisets since a product may be added more than once
+	for _, item := range subtractItems(before.Items, after.Items) {
+		changes = append(changes, FieldChange{Field: FieldItems, From: item})
+	}
+
+	for _, item := range subtractItems(after.Items, before.Items) {
+		changes = append(changes, FieldChange{Field: FieldItems, To: item})
+	}
+
+	if before.TotalAmount != after.TotalAmount {
+		changes = append(changes, FieldChange{Field: FieldTotalAmount, From: before.TotalAmount, To: after.TotalAmount})
+	}
+
+	return changes
+}
+
+// subtractItems returns the items of a that are not matched by an equal item in b
+func subtractItems(a, b []OrderItem) []OrderItem {
+
+	remaining := make(map[OrderItem]int, len(b))
+
+	for _, item := range b {
+		remaining[item]++
+	}
+
+	var result []OrderItem
+
+	for _, item := range a {
+
+		if remaining[item] > 0 {
+			remaining[item]--
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// clone copies the order state so later events do not modify the copy
+func (o *Order) clone() *Order {
+
+	c := *o
+	c.Items = append(make([]OrderItem, 0, len(o.Items)), o.Items...)
+	c.changes = nil
+
+	return &c
+}
@@ -1,11 +1,25 @@
 package order
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/events"
 	"github.com/google/uuid"
 )
 
+// AggregateType is the stream type under which order events are stored
+const AggregateType = "Order"
+
+// eventVersion is the schema version of the order event payloads
+const eventVersion = 1
+
+// snapshotSchemaVersion is the version of the Order state layout stored in
+// snapshots. Bump it when the fields of Order change, so older snapshots are
+// rebuilt from events instead of decoded into the new layout.
+const snapshotSchemaVersion = 1
+
 type Status string
 
 const (
@@ -21,7 +35,9 @@ type OrderItem struct {
 	UnitPrice float64
 }
 
-// Order represents the order aggregate root
+// Order represents the order aggregate root.
+// State is only changed by applying events; command methods validate,
+// then record an event that Apply folds into the state.
 type Order struct {
 	ID         uuid.UUID
 	CustomerID uuid.UUID
@@ -35,26 +51,55 @@ type Order struct {
 	UpdatedAt time.Time
 
 	Version int
+
+	changes []events.Event
 }
 
-func NewOrder(customerID uuid.UUID) *Order {
+func NewOrder(customerID uuid.UUID) (*Order, error) {
+
+	o := &Order{
+		ID:    uuid.New(),
+		Items: make([]OrderItem, 0),
+	}
 
-	now := time.Now()
+	err := o.raise(events.OrderCreatedEvent{
+		CustomerID: customerID,
+		CreatedAt:  time.Now(),
+	})
 
-	return &Order{
+	if err != nil {
+		return nil, err
+	}
 
-		ID: uuid.New(),
+	return o, nil
+}
 
-		CustomerID: customerID,
-		Status:     StatusDraft,
+// Empty returns an order with no state, ready to have its history applied
+func Empty(id uuid.UUID) *Order {
 
+	return &Order{
+		ID:    id,
 		Items: make([]OrderItem, 0),
+	}
+}
 
-		CreatedAt: now,
-		UpdatedAt: now,
+// LoadFromHistory rebuilds an order by applying its stored events in order
+func LoadFromHistory(history []events.Event) (*Order, error) {
 
-		Version: 1,
+	if len(history) == 0 {
+		return nil, ErrNoHistory
 	}
+
+	o := Empty(history[0].AggregateID)
+
+	for _, event := range history {
+
+		if err := o.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
 }
 
 func (o *Order) AddItem(productID uuid.UUID, quantity int, unitPrice float64) error {
@@ -63,20 +108,17 @@ func (o *Order) AddItem(productID uuid.UUID, quantity int, unitPrice float64) er
 		return ErrOrderNotInDraftState
 	}
 
-	item := OrderItem{
+	// Each product has a single line; remove it first to change the quantity
+	if o.findItem(productID) >= 0 {
+		return ErrItemAlreadyAdded
+	}
+
+	return o.raise(events.OrderItemAddedEvent{
 
 		ProductID: productID,
 		Quantity:  quantity,
 		UnitPrice: unitPrice,
-	}
-
-	o.Items = append(o.Items, item)
-	o.TotalAmount += float64(quantity) * unitPrice
-
-	o.UpdatedAt = time.Now()
-	o.Version++
-
-	return nil
+	})
 }
 
 func (o *Order) RemoveItem(productID uuid.UUID) error {
@@ -85,54 +127,152 @@ func (o *Order) RemoveItem(productID uuid.UUID) error {
 		return ErrOrderNotInDraftState
 	}
 
-	for i, item := range o.Items {
+	if o.findItem(productID) < 0 {
+		return ErrItemNotFound
+	}
 
-		if item.ProductID == productID {
+	return o.raise(events.OrderItemRemovedEvent{
+		ProductID: productID,
+	})
+}
 
-			o.TotalAmount -= float64(item.Quantity) * item.UnitPrice
-			o.Items = append(o.Items[:i], o.Items[i+1:]...)
+func (o *Order) Submit() error {
 
-			o.UpdatedAt = time.Now()
-			o.Version++
+	if o.Status != StatusDraft {
+		return ErrOrderNotInDraftState
+	}
 
-			return nil
+	if len(o.Items) == 0 {
+		return ErrOrderHasNoItems
+	}
 
-		}
+	return o.raise(events.OrderSubmittedEvent{
+		SubmittedAt: time.Now(),
+	})
+}
 
+func (o *Order) Cancel(reason string) error {
+
+	if o.Status != StatusDraft && o.Status != StatusSubmitted {
+		return ErrOrderCannotBeCancelled
 	}
 
-	return ErrItemNotFound
+	return o.raise(events.OrderCancelledEvent{
+		CancelledAt: time.Now(),
+		Reason:      reason,
+	})
 }
 
-func (o *Order) Submit() error {
+// Apply folds a single event into the order state
+func (o *Order) Apply(event events.Event) error {
 
-	if o.Status != StatusDraft {
-		return ErrOrderNotInDraftState
+	payload, err := eventTypes.Decode(event)
+
+	if errors.Is(err, events.ErrUnknownEventType) {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, event.EventType)
 	}
 
-	if len(o.Items) == 0 {
-		return ErrOrderHasNoItems
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", event.EventType, err)
 	}
 
-	o.Status = StatusSubmitted
+	switch e := payload.(type) {
+
+	case events.OrderCreatedEvent:
+
+		o.CustomerID = e.CustomerID
+		o.Status = StatusDraft
+		o.CreatedAt = e.CreatedAt
+
+	case events.OrderItemAddedEvent:
+
+		o.Items = append(o.Items, OrderItem{
+
+			ProductID: e.ProductID,
+			Quantity:  e.Quantity,
+			UnitPrice: e.UnitPrice,
+		})
+		o.TotalAmount += float64(e.Quantity) * e.UnitPrice
+
+	case events.OrderItemRemovedEvent:
+
+		// Older streams may hold several lines of one product; all are removed
+		for i := o.findItem(e.ProductID); i >= 0; i = o.findItem(e.ProductID) {
+
+			item := o.Items[i]
+			o.TotalAmount -= float64(item.Quantity) * item.UnitPrice
+			o.Items = append(o.Items[:i], o.Items[i+1:]...)
+		}
+
+	case events.OrderSubmittedEvent:
+
+		o.Status = StatusSubmitted
+
+	case events.OrderCancelledEvent:
+
+		o.Status = StatusCancelled
+	}
 
-	o.UpdatedAt = time.Now()
-	o.Version++
+	o.UpdatedAt = event.CreatedAt
+	o.Version = int(event.Sequence)
 
 	return nil
 }
 
-func (o *Order) Cancel() error {
+// AggregateID returns the ID of the order's event stream
+func (o *Order) AggregateID() uuid.UUID {
+	return o.ID
+}
 
-	if o.Status != StatusDraft && o.Status != StatusSubmitted {
-		return ErrOrderCannotBeCancelled
+// AggregateVersion returns the sequence number of the last applied event
+func (o *Order) AggregateVersion() int64 {
+	return int64(o.Version)
+}
+
+// SnapshotSchemaVersion returns the version of the state layout stored in snapshots
+func (o *Order) SnapshotSchemaVersion() int {
+	return snapshotSchemaVersion
+}
+
+// UncommittedEvents returns the events recorded since the order was loaded or last committed
+func (o *Order) UncommittedEvents() []events.Event {
+	return o.changes
+}
+
+// MarkCommitted clears the uncommitted events once they have been persisted
+func (o *Order) MarkCommitted() {
+	o.changes = nil
+}
+
+// raise records a new event and applies it to the current state.
+// The state is left unchanged when the event cannot be encoded or applied.
+func (o *Order) raise(payload interface{}) error {
+
+	encoded, err := eventTypes.Encode(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode %T: %w", payload, err)
 	}
 
-	o.Status = StatusCancelled
+	event := events.NewEvent(AggregateType, o.ID, encoded.EventType, encoded.EventVersion, int64(o.Version)+1, encoded.Data, encoded.Metadata)
 
-	o.UpdatedAt = time.Now()
+	if err := o.Apply(event); err != nil {
+		return fmt.Errorf("failed to apply %s: %w", event.EventType, err)
+	}
 
-	o.Version++
+	o.changes = append(o.changes, event)
 
 	return nil
 }
+
+func (o *Order) findItem(productID uuid.UUID) int {
+
+	for i, item := range o.Items {
+
+		if item.ProductID == productID {
+			return i
+		}
+	}
+
+	return -1
+}
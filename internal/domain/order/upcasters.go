@@ -0,0 +1,10 @@
+package order
+
+import "github.com/HarshavardhanK/espm/internal/events"
+
+// Upcasters returns the schema migrations for order events.
+// When an order event payload changes, bump eventVersion and register the
+// transform from the previous version here so stored history stays readable.
+func Upcasters() *events.UpcasterRegistry {
+	return events.NewUpcasterRegistry()
+}
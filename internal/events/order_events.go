@@ -25,9 +25,16 @@ type Event struct {
 	EventType     EventType
 	EventVersion  int
 	Sequence      int64
+	Position      int64 // global commit position, assigned by the store
 	Data          []byte
 	Metadata      map[string]interface{}
 	CreatedAt     time.Time
+
+	// Tracing fields, filled from the request context when left empty
+	CorrelationID string
+	CausationID   string
+	UserID        string
+	TraceID       string
 }
 
 // OrderCreatedEvent represents the event when an order is created
@@ -0,0 +1,215 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrUnknownEventType is returned for event types or payloads that are not registered
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrInvalidPayload is returned when a payload does not match its registered schema
+	ErrInvalidPayload = errors.New("invalid event payload")
+)
+
+// registration is the payload type and schema version registered for an event type
+type registration struct {
+	payloadType reflect.Type
+	version     int
+}
+
+// TypeRegistry maps event types to the Go types of their payloads,
+// so consumers can decode events without knowing which struct to use
+type TypeRegistry struct {
+	mu          sync.RWMutex
+	byEventType map[EventType]registration
+	byGoType    map[reflect.Type]EventType
+	serializer  Serializer
+}
+
+// NewTypeRegistry creates an empty type registry that encodes payloads as JSON
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		byEventType: make(map[EventType]registration),
+		byGoType:    make(map[reflect.Type]EventType),
+		serializer:  jsonSerializer{},
+	}
+}
+
+// WithSerializer changes the format new payloads are encoded in.
+// Events are always decoded with the format recorded in their metadata,
+// so streams mixing formats stay readable.
+func (r *TypeRegistry) WithSerializer(serializer Serializer) *TypeRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.serializer = serializer
+	return r
+}
+
+// Register maps an event type to a payload struct at its latest schema version.
+// payload is a zero value of the struct, e.g. OrderCreatedEvent{}.
+func (r *TypeRegistry) Register(eventType EventType, version int, payload interface{}) error {
+	payloadType := structType(payload)
+	if payloadType == nil {
+		return fmt.Errorf("payload for %s must be a struct, got %T", eventType, payload)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byEventType[eventType]; exists {
+		return fmt.Errorf("event type %s is already registered", eventType)
+	}
+	if existing, exists := r.byGoType[payloadType]; exists {
+		return fmt.Errorf("payload %s is already registered for %s", payloadType, existing)
+	}
+
+	r.byEventType[eventType] = registration{payloadType: payloadType, version: version}
+	r.byGoType[payloadType] = eventType
+
+	return nil
+}
+
+// MustRegister is like Register but panics on an invalid registration
+func (r *TypeRegistry) MustRegister(eventType EventType, version int, payload interface{}) {
+	if err := r.Register(eventType, version, payload); err != nil {
+		panic(err)
+	}
+}
+
+// Known reports whether an event type is registered
+func (r *TypeRegistry) Known(eventType EventType) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.byEventType[eventType]
+	return ok
+}
+
+// Encode serializes a registered payload into an event carrying its type,
+// schema version, data and format metadata. Stream fields are left for the
+// caller to fill in.
+func (r *TypeRegistry) Encode(payload interface{}) (Event, error) {
+	payloadType := structType(payload)
+
+	r.mu.RLock()
+	eventType, ok := r.byGoType[payloadType]
+	reg := r.byEventType[eventType]
+	serializer := r.serializer
+	r.mu.RUnlock()
+
+	if !ok {
+		return Event{}, fmt.Errorf("%w: payload %T", ErrUnknownEventType, payload)
+	}
+
+	data, err := serializer.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s: %w", eventType, err)
+	}
+
+	return Event{
+		EventType:    eventType,
+		EventVersion: reg.version,
+		Data:         data,
+		Metadata:     map[string]interface{}{MetadataFormatKey: string(serializer.Format())},
+	}, nil
+}
+
+// Decode returns the event's payload as a value of its registered struct type.
+// Events of unregistered types fail with ErrUnknownEventType, which consumers
+// that only handle a subset of events can check for and skip.
+func (r *TypeRegistry) Decode(event Event) (interface{}, error) {
+	reg, serializer, err := r.lookup(event)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := reflect.New(reg.payloadType)
+	if err := serializer.Unmarshal(event.Data, payload.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.EventType, err)
+	}
+
+	return payload.Elem().Interface(), nil
+}
+
+// Validate strictly checks an event against its registered schema: the type must
+// be known, the version current, and the payload must decode without unknown fields
+func (r *TypeRegistry) Validate(event Event) error {
+	reg, serializer, err := r.lookup(event)
+	if err != nil {
+		return err
+	}
+
+	payload := reflect.New(reg.payloadType)
+	if err := serializer.UnmarshalStrict(event.Data, payload.Interface()); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.EventType, err)
+	}
+
+	return nil
+}
+
+// ValidateAll validates every event, stopping at the first failure
+func (r *TypeRegistry) ValidateAll(events []Event) error {
+	for _, event := range events {
+		if err := r.Validate(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookup finds the registration of an event, checks its schema version and
+// returns the serializer of its recorded format
+func (r *TypeRegistry) lookup(event Event) (registration, Serializer, error) {
+	r.mu.RLock()
+	reg, ok := r.byEventType[event.EventType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return reg, nil, fmt.Errorf("%w: %s", ErrUnknownEventType, event.EventType)
+	}
+
+	if event.EventVersion != reg.version {
+		return reg, nil, fmt.Errorf("%w: %s is v%d, expected v%d", ErrInvalidPayload, event.EventType, event.EventVersion, reg.version)
+	}
+
+	serializer, err := SerializerFor(FormatOf(event))
+	if err != nil {
+		return reg, nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.EventType, err)
+	}
+
+	return reg, serializer, nil
+}
+
+// DecodeAs decodes an event and asserts its payload type
+func DecodeAs[T any](r *TypeRegistry, event Event) (T, error) {
+	var zero T
+
+	payload, err := r.Decode(event)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := payload.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s decodes to %T, not %T", ErrInvalidPayload, event.EventType, payload, zero)
+	}
+
+	return typed, nil
+}
+
+// structType returns the struct type of a value or pointer to struct
+func structType(value interface{}) reflect.Type {
+	t := reflect.TypeOf(value)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
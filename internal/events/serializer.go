@@ -0,0 +1,129 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Format identifies a payload serialization format
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatMsgpack Format = "msgpack"
+)
+
+// MetadataFormatKey is the event metadata key recording the payload format.
+// Events without it predate serializers and are JSON.
+const MetadataFormatKey = "format"
+
+// ErrUnknownFormat is returned for serialization formats without a serializer
+var ErrUnknownFormat = errors.New("unknown serialization format")
+
+// Serializer converts values to and from a serialization format
+type Serializer interface {
+	Format() Format
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// UnmarshalStrict is like Unmarshal but rejects fields v does not declare
+	UnmarshalStrict(data []byte, v interface{}) error
+}
+
+// serializers holds the built-in serializers by format
+var serializers = map[Format]Serializer{
+	FormatJSON:    jsonSerializer{},
+	FormatMsgpack: newMsgpackSerializer(),
+}
+
+// SerializerFor returns the serializer of a format. An empty format means JSON.
+func SerializerFor(format Format) (Serializer, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+
+	serializer, ok := serializers[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	return serializer, nil
+}
+
+// FormatOf returns the format an event's payload was serialized with
+func FormatOf(event Event) Format {
+	if format, ok := event.Metadata[MetadataFormatKey].(string); ok && format != "" {
+		return Format(format)
+	}
+	return FormatJSON
+}
+
+// jsonSerializer serializes with encoding/json
+type jsonSerializer struct{}
+
+func (jsonSerializer) Format() Format {
+	return FormatJSON
+}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonSerializer) UnmarshalStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("trailing data after payload")
+	}
+
+	return nil
+}
+
+// msgpackSerializer serializes with MessagePack
+type msgpackSerializer struct {
+	handle       *codec.MsgpackHandle
+	strictHandle *codec.MsgpackHandle
+}
+
+func newMsgpackSerializer() msgpackSerializer {
+	newHandle := func(strict bool) *codec.MsgpackHandle {
+		h := &codec.MsgpackHandle{WriteExt: true}
+		h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+		h.RawToString = true
+		h.ErrorIfNoField = strict
+		return h
+	}
+
+	return msgpackSerializer{handle: newHandle(false), strictHandle: newHandle(true)}
+}
+
+func (msgpackSerializer) Format() Format {
+	return FormatMsgpack
+}
+
+func (s msgpackSerializer) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, s.handle).Encode(v)
+	return data, err
+}
+
+func (s msgpackSerializer) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, s.handle).Decode(v)
+}
+
+func (s msgpackSerializer) UnmarshalStrict(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, s.strictHandle).Decode(v)
+}
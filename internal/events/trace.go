@@ -0,0 +1,63 @@
+package events
+
+import "context"
+
+// Trace identifies why events were recorded. The correlation ID is shared by
+// everything that follows from one user action, the causation ID names the
+// command or event that directly caused them, and the trace ID links them to
+// distributed traces.
+type Trace struct {
+	CorrelationID string
+	CausationID   string
+	UserID        string
+	TraceID       string
+}
+
+type traceKey struct{}
+
+// ContextWithTrace returns a context carrying trace for the events recorded under it
+func ContextWithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFromContext returns the trace carried by ctx, or an empty trace
+func TraceFromContext(ctx context.Context) Trace {
+	trace, _ := ctx.Value(traceKey{}).(Trace)
+	return trace
+}
+
+// TraceFromEvent returns the trace for work caused by an event: it keeps the
+// event's correlation, user and trace IDs and names the event as the cause
+func TraceFromEvent(event Event) Trace {
+	correlationID := event.CorrelationID
+	if correlationID == "" {
+		correlationID = event.EventID.String()
+	}
+
+	return Trace{
+		CorrelationID: correlationID,
+		CausationID:   event.EventID.String(),
+		UserID:        event.UserID,
+		TraceID:       event.TraceID,
+	}
+}
+
+// StampTrace fills the empty tracing fields of events in place from the trace in ctx
+func StampTrace(ctx context.Context, events []Event) {
+	trace := TraceFromContext(ctx)
+
+	for i := range events {
+		if events[i].CorrelationID == "" {
+			events[i].CorrelationID = trace.CorrelationID
+		}
+		if events[i].CausationID == "" {
+			events[i].CausationID = trace.CausationID
+		}
+		if events[i].UserID == "" {
+			events[i].UserID = trace.UserID
+		}
+		if events[i].TraceID == "" {
+			events[i].TraceID = trace.TraceID
+		}
+	}
+}
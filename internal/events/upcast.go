@@ -0,0 +1,115 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Upcaster transforms a JSON event payload from one schema version to the next
+type Upcaster func(data []byte) ([]byte, error)
+
+// JSONUpcaster builds an Upcaster that edits a JSON payload as a generic map
+func JSONUpcaster(transform func(payload map[string]interface{}) error) Upcaster {
+	return func(data []byte) ([]byte, error) {
+		payload := make(map[string]interface{})
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := transform(payload); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(payload)
+	}
+}
+
+// upcastKey identifies the schema version an upcaster starts from
+type upcastKey struct {
+	eventType   EventType
+	fromVersion int
+}
+
+// UpcasterRegistry chains upcasters so events read from the store always
+// reach the domain in their latest schema version
+type UpcasterRegistry struct {
+	mu        sync.RWMutex
+	upcasters map[upcastKey]Upcaster
+}
+
+// NewUpcasterRegistry creates an empty upcaster registry
+func NewUpcasterRegistry() *UpcasterRegistry {
+	return &UpcasterRegistry{upcasters: make(map[upcastKey]Upcaster)}
+}
+
+// Register adds the transform from fromVersion to fromVersion+1 of an event type
+func (r *UpcasterRegistry) Register(eventType EventType, fromVersion int, upcaster Upcaster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := upcastKey{eventType: eventType, fromVersion: fromVersion}
+	if _, exists := r.upcasters[key]; exists {
+		return fmt.Errorf("upcaster for %s v%d is already registered", eventType, fromVersion)
+	}
+
+	r.upcasters[key] = upcaster
+	return nil
+}
+
+// MustRegister is like Register but panics on a duplicate registration
+func (r *UpcasterRegistry) MustRegister(eventType EventType, fromVersion int, upcaster Upcaster) {
+	if err := r.Register(eventType, fromVersion, upcaster); err != nil {
+		panic(err)
+	}
+}
+
+// Upcast applies every registered step starting at the event's version.
+// Events already at their latest version are returned unchanged.
+func (r *UpcasterRegistry) Upcast(event Event) (Event, error) {
+	if r == nil {
+		return event, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for {
+		upcaster, ok := r.upcasters[upcastKey{eventType: event.EventType, fromVersion: event.EventVersion}]
+		if !ok {
+			return event, nil
+		}
+
+		// Binary payloads cannot be transformed without their old Go type
+		if format := FormatOf(event); format != FormatJSON {
+			return event, fmt.Errorf("failed to upcast %s %s from v%d: %s payloads cannot be upcast", event.EventType, event.EventID, event.EventVersion, format)
+		}
+
+		data, err := upcaster(event.Data)
+		if err != nil {
+			return event, fmt.Errorf("failed to upcast %s %s from v%d: %w", event.EventType, event.EventID, event.EventVersion, err)
+		}
+
+		event.Data = data
+		event.EventVersion++
+	}
+}
+
+// UpcastAll upcasts a slice of events in place
+func (r *UpcasterRegistry) UpcastAll(events []Event) ([]Event, error) {
+	if r == nil {
+		return events, nil
+	}
+
+	for i := range events {
+		upcasted, err := r.Upcast(events[i])
+		if err != nil {
+			return nil, err
+		}
+		events[i] = upcasted
+	}
+
+	return events, nil
+}
@@ -0,0 +1,268 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/pkg/messaging"
+	"github.com/google/uuid"
+)
+
+// envelope is the wire format of a published event. Data holds JSON payloads
+// inline and binary payloads as bytes; metadata records the payload format.
+type envelope struct {
+	EventID       uuid.UUID              `json:"eventId" codec:"eventId"`
+	AggregateType string                 `json:"aggregateType" codec:"aggregateType"`
+	AggregateID   uuid.UUID              `json:"aggregateId" codec:"aggregateId"`
+	EventType     events.EventType       `json:"eventType" codec:"eventType"`
+	EventVersion  int                    `json:"eventVersion" codec:"eventVersion"`
+	Sequence      int64                  `json:"sequence" codec:"sequence"`
+	Position      int64                  `json:"position" codec:"position"`
+	Data          interface{}            `json:"data" codec:"data"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty" codec:"metadata,omitempty"`
+	CreatedAt     time.Time              `json:"createdAt" codec:"createdAt"`
+	CorrelationID string                 `json:"correlationId,omitempty" codec:"correlationId,omitempty"`
+	CausationID   string                 `json:"causationId,omitempty" codec:"causationId,omitempty"`
+	UserID        string                 `json:"userId,omitempty" codec:"userId,omitempty"`
+	TraceID       string                 `json:"traceId,omitempty" codec:"traceId,omitempty"`
+}
+
+// NewMessage converts an event to a message keyed by its aggregate, so brokers
+// that partition by key preserve per-aggregate order. The envelope is encoded
+// with serializer; a nil serializer means JSON.
+func NewMessage(topic string, event events.Event, serializer events.Serializer) (messaging.Message, error) {
+	if serializer == nil {
+		serializer, _ = events.SerializerFor(events.FormatJSON)
+	}
+
+	var data interface{} = event.Data
+	if events.FormatOf(event) == events.FormatJSON && serializer.Format() == events.FormatJSON {
+		data = json.RawMessage(event.Data)
+	}
+
+	value, err := serializer.Marshal(envelope{
+		EventID:       event.EventID,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		EventType:     event.EventType,
+		EventVersion:  event.EventVersion,
+		Sequence:      event.Sequence,
+		Position:      event.Position,
+		Data:          data,
+		Metadata:      event.Metadata,
+		CreatedAt:     event.CreatedAt,
+		CorrelationID: event.CorrelationID,
+		CausationID:   event.CausationID,
+		UserID:        event.UserID,
+		TraceID:       event.TraceID,
+	})
+	if err != nil {
+		return messaging.Message{}, err
+	}
+
+	contentType := "application/x-" + string(serializer.Format())
+	if serializer.Format() == events.FormatJSON {
+		contentType = messaging.ContentTypeJSON
+	}
+
+	headers := map[string]string{
+		messaging.HeaderContentType: contentType,
+		"event-id":                  event.EventID.String(),
+		"event-type":                string(event.EventType),
+		"aggregate-type":            event.AggregateType,
+		"sequence":                  strconv.FormatInt(event.Sequence, 10),
+	}
+	if event.CorrelationID != "" {
+		headers["correlation-id"] = event.CorrelationID
+	}
+	if event.CausationID != "" {
+		headers["causation-id"] = event.CausationID
+	}
+
+	return messaging.Message{
+		Topic:   topic,
+		Key:     event.AggregateID.String(),
+		Headers: headers,
+		Value:   value,
+	}, nil
+}
+
+// Relay drains the outbox into a Publisher
+type Relay struct {
+	store      repository.OutboxStore
+	publisher  messaging.Publisher
+	serializer events.Serializer
+	cfg        config.OutboxConfig
+	owner      string
+	notifier   repository.CommitNotifier
+}
+
+// NewRelay creates a new outbox relay. Each relay takes leases under its own
+// owner ID, so several replicas can drain the same outbox.
+func NewRelay(store repository.OutboxStore, publisher messaging.Publisher, cfg config.OutboxConfig) (*Relay, error) {
+	serializer, err := events.SerializerFor(events.Format(cfg.Format))
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := config.DefaultOutboxConfig()
+	if cfg.Topic == "" {
+		cfg.Topic = defaults.Topic
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.LeaseDuration <= 0 {
+		cfg.LeaseDuration = defaults.LeaseDuration
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaults.RetryInterval
+	}
+	if cfg.MaxRetryInterval < cfg.RetryInterval {
+		cfg.MaxRetryInterval = cfg.RetryInterval
+	}
+
+	return &Relay{
+		store:      store,
+		publisher:  publisher,
+		serializer: serializer,
+		cfg:        cfg,
+		owner:      uuid.New().String(),
+	}, nil
+}
+
+// WithNotifier wakes the relay as soon as events are committed, since their
+// outbox messages are written in the same transaction. The relay still polls
+// for retries and expired leases.
+func (r *Relay) WithNotifier(notifier repository.CommitNotifier) *Relay {
+	r.notifier = notifier
+	return r
+}
+
+// Run publishes pending messages until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) error {
+	lastPurge := time.Time{}
+
+	var commits <-chan struct{}
+	if r.notifier != nil {
+		notifyCtx, stopNotify := context.WithCancel(ctx)
+		defer stopNotify()
+
+		var err error
+		if commits, _, err = r.notifier.Notify(notifyCtx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		published, err := r.PublishPending(ctx)
+		if ctx.Err() != nil {
+			return r.store.ReleaseOutbox(context.Background(), r.owner)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to publish outbox: %v\n", err)
+		}
+
+		if r.cfg.Retention > 0 && time.Since(lastPurge) > r.cfg.Retention/24 {
+			if _, err := r.store.PurgePublished(ctx, time.Now().Add(-r.cfg.Retention)); err != nil {
+				fmt.Printf("Warning: failed to purge outbox: %v\n", err)
+			}
+			lastPurge = time.Now()
+		}
+
+		// Keep draining while there is a backlog
+		if err == nil && published > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return r.store.ReleaseOutbox(context.Background(), r.owner)
+		case <-time.After(r.cfg.PollInterval):
+		case <-commits:
+		}
+	}
+}
+
+// PublishPending runs a single claim and publish round and returns the number
+// of messages published. Each aggregate's messages are sent in order, and an
+// aggregate stops at its first failure so later events never overtake it.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	messages, err := r.store.ClaimOutbox(ctx, r.owner, r.cfg.LeaseDuration, r.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox: %w", err)
+	}
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	published := 0
+	blocked := make(map[string]bool)
+
+	for _, message := range messages {
+		key := message.Event.AggregateType + ":" + message.Event.AggregateID.String()
+		if blocked[key] {
+			continue
+		}
+
+		if err := r.publish(ctx, message); err != nil {
+			blocked[key] = true
+
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		published++
+	}
+
+	// Leases on messages skipped this round must not outlive it
+	if err := r.store.ReleaseOutbox(ctx, r.owner); err != nil {
+		return published, fmt.Errorf("failed to release outbox leases: %w", err)
+	}
+
+	return published, nil
+}
+
+// publish sends one message and records the outcome
+func (r *Relay) publish(ctx context.Context, message repository.OutboxMessage) error {
+	msg, err := NewMessage(r.cfg.Topic, message.Event, r.serializer)
+	if err == nil {
+		err = r.publisher.Publish(ctx, msg)
+	}
+
+	if err != nil {
+		retryAt := time.Now().Add(r.backoff(message.Attempts + 1))
+		if markErr := r.store.MarkFailed(ctx, r.owner, message.ID, retryAt, err.Error()); markErr != nil {
+			fmt.Printf("Warning: failed to record outbox failure for message %d: %v\n", message.ID, markErr)
+		}
+		return err
+	}
+
+	// If the lease was lost the message may be sent again by its new owner,
+	// which consumers tolerate since delivery is at least once
+	return r.store.MarkPublished(ctx, r.owner, message.ID)
+}
+
+// backoff returns the retry delay after the given number of attempts
+func (r *Relay) backoff(attempts int) time.Duration {
+	wait := r.cfg.RetryInterval
+	for i := 1; i < attempts && wait < r.cfg.MaxRetryInterval; i++ {
+		wait *= 2
+	}
+	if wait > r.cfg.MaxRetryInterval {
+		wait = r.cfg.MaxRetryInterval
+	}
+	return wait
+}
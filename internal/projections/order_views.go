@@ -0,0 +1,159 @@
+package projections
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+)
+
+// OrderViewsProjection is the name of the projection maintaining the order_views table
+const OrderViewsProjection = "order_views"
+
+// NewOrderViewProjection returns the projection that maintains the order read model
+func NewOrderViewProjection() Projection {
+	return Projection{
+		Name: OrderViewsProjection,
+		Handlers: map[events.EventType]Handler{
+			events.OrderCreatedEventType:     orderCreated,
+			events.OrderItemAddedEventType:   orderItemAdded,
+			events.OrderItemRemovedEventType: orderItemRemoved,
+			events.OrderSubmittedEventType:   orderStatusChanged(order.StatusSubmitted),
+			events.OrderCancelledEventType:   orderStatusChanged(order.StatusCancelled),
+		},
+		Reset: func(ctx context.Context, tx repository.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM order_views`)
+			return err
+		},
+	}
+}
+
+func orderCreated(ctx context.Context, tx repository.Tx, event events.Event) error {
+	e, err := events.DecodeAs[events.OrderCreatedEvent](order.EventTypes(), event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO order_views (
+			order_id, customer_id, status, items, item_count,
+			total_amount, version, created_at, updated_at
+		) VALUES ($1, $2, $3, '[]', 0, 0, $4, $5, $6)
+	`,
+		event.AggregateID,
+		e.CustomerID,
+		order.StatusDraft,
+		event.Sequence,
+		e.CreatedAt,
+		event.CreatedAt,
+	)
+
+	return err
+}
+
+func orderItemAdded(ctx context.Context, tx repository.Tx, event events.Event) error {
+	e, err := events.DecodeAs[events.OrderItemAddedEvent](order.EventTypes(), event)
+	if err != nil {
+		return err
+	}
+
+	return updateOrderView(ctx, tx, event, func(view *repository.OrderView) {
+		view.Items = append(view.Items, repository.OrderViewItem{
+			ProductID: e.ProductID,
+			Quantity:  e.Quantity,
+			UnitPrice: e.UnitPrice,
+		})
+	})
+}
+
+func orderItemRemoved(ctx context.Context, tx repository.Tx, event events.Event) error {
+	e, err := events.DecodeAs[events.OrderItemRemovedEvent](order.EventTypes(), event)
+	if err != nil {
+		return err
+	}
+
+	// Every line of the product is removed, as the aggregate does
+	return updateOrderView(ctx, tx, event, func(view *repository.OrderView) {
+		items := view.Items[:0]
+		for _, item := range view.Items {
+			if item.ProductID != e.ProductID {
+				items = append(items, item)
+			}
+		}
+		view.Items = items
+	})
+}
+
+func orderStatusChanged(status order.Status) Handler {
+	return func(ctx context.Context, tx repository.Tx, event events.Event) error {
+		return updateOrderView(ctx, tx, event, func(view *repository.OrderView) {
+			view.Status = string(status)
+		})
+	}
+}
+
+// updateOrderView locks an order view, applies mutate and writes it back,
+// recomputing the derived columns and stamping the event's version
+func updateOrderView(
+	ctx context.Context,
+	tx repository.Tx,
+	event events.Event,
+	mutate func(view *repository.OrderView),
+) error {
+	view := repository.OrderView{ID: event.AggregateID}
+	var itemsJSON []byte
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, items
+		FROM order_views
+		WHERE order_id = $1
+		FOR UPDATE
+	`, event.AggregateID).Scan(&view.Status, &itemsJSON)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s", repository.ErrOrderViewNotFound, event.AggregateID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(itemsJSON, &view.Items); err != nil {
+		return err
+	}
+
+	mutate(&view)
+
+	for _, item := range view.Items {
+		view.TotalAmount += float64(item.Quantity) * item.UnitPrice
+	}
+
+	if view.Items == nil {
+		view.Items = []repository.OrderViewItem{}
+	}
+
+	itemsJSON, err = json.Marshal(view.Items)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE order_views
+		SET status = $1, items = $2, item_count = $3, total_amount = $4,
+			version = $5, updated_at = $6
+		WHERE order_id = $7
+	`,
+		view.Status,
+		itemsJSON,
+		len(view.Items),
+		view.TotalAmount,
+		event.Sequence,
+		event.CreatedAt,
+		event.AggregateID,
+	)
+
+	return err
+}
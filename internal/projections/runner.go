@@ -0,0 +1,356 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+)
+
+// Handler applies a single event to a read model inside the checkpoint transaction
+type Handler func(ctx context.Context, tx repository.Tx, event events.Event) error
+
+// Projection is a named read model built from the global event log
+type Projection struct {
+	Name string
+	// Handlers maps the event types the projection cares about to their handlers.
+	// Events of other types only advance the checkpoint.
+	Handlers map[events.EventType]Handler
+	// Reset clears the read model before a rebuild
+	Reset repository.ResetFunc
+}
+
+// RunnerConfig holds projection runner settings
+type RunnerConfig struct {
+	// RetryInterval and MaxRetryInterval bound the backoff after a failed batch
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	// MaxAttempts is the number of consecutive failures after which a projection
+	// is marked failed and parked until it is rebuilt
+	MaxAttempts int
+}
+
+// DefaultRunnerConfig returns default projection runner configuration
+func DefaultRunnerConfig() RunnerConfig {
+	return RunnerConfig{
+		RetryInterval:    time.Second,
+		MaxRetryInterval: time.Minute,
+		MaxAttempts:      10,
+	}
+}
+
+// CheckpointStore persists projection checkpoints and statuses for the runner
+type CheckpointStore interface {
+	// GetCheckpoint returns the position of the last event applied by a projection
+	GetCheckpoint(ctx context.Context, projectionName string) (int64, error)
+
+	// ApplyBatch applies a batch with apply and advances the checkpoint past it in
+	// one transaction. It fails with ErrCheckpointMoved if the checkpoint is no
+	// longer fromCheckpoint.
+	ApplyBatch(ctx context.Context, projectionName string, fromCheckpoint int64, batch []events.Event, apply repository.ApplyFunc) (int64, error)
+
+	// UpdateProjectionStatus records the status of a projection and its last error
+	UpdateProjectionStatus(ctx context.Context, projectionName string, status repository.ProjectionStatus, lastError string) error
+
+	// ResetProjection clears a read model with reset and rewinds its checkpoint to zero
+	ResetProjection(ctx context.Context, projectionName string, reset repository.ResetFunc) error
+}
+
+// errRebuildRequested ends a projection's subscription when a rebuild is requested
+var errRebuildRequested = errors.New("projection rebuild requested")
+
+// Runner drives registered projections from their stored checkpoints
+type Runner struct {
+	subscriber repository.EventSubscriber
+	store      CheckpointStore
+	cfg        RunnerConfig
+
+	mu      sync.Mutex
+	workers map[string]*worker
+	order   []string
+}
+
+// worker is the runtime state of a single registered projection
+type worker struct {
+	projection Projection
+	rebuild    chan struct{}
+}
+
+// NewRunner creates a new projection runner. Each projection subscribes to
+// the event log from its checkpoint and applies the events it is handed.
+func NewRunner(subscriber repository.EventSubscriber, store CheckpointStore, cfg RunnerConfig) *Runner {
+	defaults := DefaultRunnerConfig()
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaults.RetryInterval
+	}
+	if cfg.MaxRetryInterval < cfg.RetryInterval {
+		cfg.MaxRetryInterval = cfg.RetryInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+
+	return &Runner{
+		subscriber: subscriber,
+		store:      store,
+		cfg:        cfg,
+		workers:    make(map[string]*worker),
+	}
+}
+
+// Register adds a projection to the runner. It must be called before Run.
+func (r *Runner) Register(projection Projection) error {
+	if projection.Name == "" {
+		return errors.New("projection name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.workers[projection.Name]; exists {
+		return fmt.Errorf("projection %q is already registered", projection.Name)
+	}
+
+	r.workers[projection.Name] = &worker{
+		projection: projection,
+		rebuild:    make(chan struct{}, 1),
+	}
+	r.order = append(r.order, projection.Name)
+
+	return nil
+}
+
+// Run runs every registered projection until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	r.mu.Lock()
+	workers := make([]*worker, 0, len(r.order))
+	for _, name := range r.order {
+		workers = append(workers, r.workers[name])
+	}
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			r.run(ctx, w)
+		}(w)
+	}
+
+	wg.Wait()
+}
+
+// Rebuild asks a projection to clear its read model and replay the log from the start.
+// A failed projection is resumed by a rebuild.
+func (r *Runner) Rebuild(name string) error {
+	r.mu.Lock()
+	w, ok := r.workers[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return repository.ErrProjectionNotFound
+	}
+
+	w.requestRebuild()
+
+	return nil
+}
+
+// run processes batches for one projection, tracking its status transitions
+func (r *Runner) run(ctx context.Context, w *worker) {
+	name := w.projection.Name
+
+	var checkpoint int64
+	status := repository.ProjectionStatus("")
+	failures := 0
+
+	report := func(next repository.ProjectionStatus, lastError string) {
+		if next == status && lastError == "" {
+			return
+		}
+		status = next
+		if err := r.store.UpdateProjectionStatus(ctx, name, next, lastError); err != nil && ctx.Err() == nil {
+			fmt.Printf("Warning: failed to update status of projection %s: %v\n", name, err)
+		}
+	}
+
+	// fail records a failed attempt and reports whether the projection gave up
+	fail := func(err error) bool {
+		failures++
+		if failures >= r.cfg.MaxAttempts {
+			report(repository.ProjectionFailed, err.Error())
+			return true
+		}
+		report(repository.ProjectionStalled, err.Error())
+		return false
+	}
+
+	// applied records a successful batch; a rebuild is done once it has caught up
+	applied := func(caughtUp bool) {
+		failures = 0
+		if status != repository.ProjectionRebuilding || caughtUp {
+			report(repository.ProjectionRunning, "")
+		}
+	}
+
+	loaded := false
+
+	for {
+		var err error
+		resetFailed := false
+
+		select {
+		case <-w.rebuild:
+			err = r.store.ResetProjection(ctx, name, w.projection.Reset)
+			if err == nil {
+				checkpoint, loaded, failures = 0, true, 0
+				status = repository.ProjectionRebuilding
+				continue
+			}
+			resetFailed = true
+		default:
+			if !loaded {
+				checkpoint, err = r.store.GetCheckpoint(ctx, name)
+				loaded = err == nil
+			}
+			if err == nil {
+				err = r.follow(ctx, w, &checkpoint, applied)
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case errors.Is(err, errRebuildRequested):
+			continue
+
+		case errors.Is(err, repository.ErrCheckpointMoved):
+			// Another runner owns this projection; reload and continue from its position
+			loaded = false
+			continue
+
+		case err != nil:
+			if fail(err) {
+				if !r.park(ctx, w) {
+					return
+				}
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.backoff(failures)):
+			case <-w.rebuild:
+				// Put the request back so the top of the loop handles it
+				w.requestRebuild()
+			}
+
+			if resetFailed {
+				w.requestRebuild()
+			}
+		}
+	}
+}
+
+// follow subscribes a projection to the event log from its checkpoint and
+// applies every batch in its own transaction, advancing checkpoint. It returns
+// when a batch fails, ctx is cancelled or a rebuild is requested.
+func (r *Runner) follow(ctx context.Context, w *worker, checkpoint *int64, applied func(caughtUp bool)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rebuilding := make(chan struct{})
+	watching := make(chan struct{})
+
+	go func() {
+		defer close(watching)
+		select {
+		case <-w.rebuild:
+			close(rebuilding)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := r.subscriber.SubscribeBatches(ctx, *checkpoint, func(batch []events.Event) error {
+		if len(batch) == 0 {
+			applied(true)
+			return nil
+		}
+
+		next, err := r.store.ApplyBatch(ctx, w.projection.Name, *checkpoint, batch, w.apply)
+		if err != nil {
+			return err
+		}
+
+		*checkpoint = next
+		applied(false)
+		return nil
+	})
+
+	cancel()
+	<-watching
+
+	select {
+	case <-rebuilding:
+		// Hand the request back to run, which resets the projection
+		w.requestRebuild()
+		return errRebuildRequested
+	default:
+		return err
+	}
+}
+
+// park blocks a failed projection until a rebuild is requested.
+// It returns false if ctx was cancelled instead.
+func (r *Runner) park(ctx context.Context, w *worker) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-w.rebuild:
+		w.requestRebuild()
+		return true
+	}
+}
+
+// backoff returns the wait after the given number of consecutive failures
+func (r *Runner) backoff(failures int) time.Duration {
+	wait := r.cfg.RetryInterval
+	for i := 1; i < failures && wait < r.cfg.MaxRetryInterval; i++ {
+		wait *= 2
+	}
+	if wait > r.cfg.MaxRetryInterval {
+		wait = r.cfg.MaxRetryInterval
+	}
+	return wait
+}
+
+// requestRebuild queues a rebuild unless one is pending already
+func (w *worker) requestRebuild() {
+	select {
+	case w.rebuild <- struct{}{}:
+	default:
+	}
+}
+
+// apply dispatches an event to the projection's handler for its type
+func (w *worker) apply(ctx context.Context, tx repository.Tx, event events.Event) error {
+	handler, ok := w.projection.Handlers[event.EventType]
+	if !ok {
+		return nil
+	}
+
+	if err := handler(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to apply %s at position %d: %w", event.EventType, event.Position, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,462 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/google/uuid"
+)
+
+// Aggregate is an event-sourced aggregate root
+type Aggregate interface {
+	// AggregateID returns the ID of the aggregate's event stream
+	AggregateID() uuid.UUID
+	// AggregateVersion returns the sequence number of the last applied event
+	AggregateVersion() int64
+	// Apply folds a stored event into the aggregate state
+	Apply(event events.Event) error
+	// UncommittedEvents returns events recorded but not yet persisted
+	UncommittedEvents() []events.Event
+	// MarkCommitted clears the uncommitted events
+	MarkCommitted()
+}
+
+// SnapshotVersioned is implemented by aggregates whose snapshots record the
+// version of their state layout. Bumping the version makes existing snapshots
+// outdated, so they are rebuilt from events instead of decoded.
+type SnapshotVersioned interface {
+	SnapshotSchemaVersion() int
+}
+
+// AggregateRepository loads and saves aggregates of a single type
+// from a snapshot plus the events written after it
+type AggregateRepository[T Aggregate] struct {
+	aggregateType string
+	events        EventStore
+	snapshots     SnapshotStore
+	factory       func(id uuid.UUID) T
+	policy        SnapshotPolicy
+	snapshotter   *AsyncSnapshotter
+	replays       *replayTracker
+	schemaVersion int
+}
+
+// NewAggregateRepository creates a new aggregate repository.
+// factory must return an empty aggregate ready to have its history applied.
+func NewAggregateRepository[T Aggregate](
+	aggregateType string,
+	eventStore EventStore,
+	snapshotStore SnapshotStore,
+	factory func(id uuid.UUID) T,
+	policy SnapshotPolicy,
+) *AggregateRepository[T] {
+
+	if policy == nil {
+		policy = NeverSnapshot()
+	}
+
+	schemaVersion := 0
+	if versioned, ok := any(factory(uuid.Nil)).(SnapshotVersioned); ok {
+		schemaVersion = versioned.SnapshotSchemaVersion()
+	}
+
+	return &AggregateRepository[T]{
+
+		aggregateType: aggregateType,
+		events:        eventStore,
+		snapshots:     snapshotStore,
+		factory:       factory,
+		policy:        policy,
+		replays:       newReplayTracker(),
+		schemaVersion: schemaVersion,
+	}
+}
+
+// WithAsyncSnapshots evaluates the snapshot policy and writes snapshots on
+// snapshotter instead of inside Save
+func (r *AggregateRepository[T]) WithAsyncSnapshots(snapshotter *AsyncSnapshotter) *AggregateRepository[T] {
+
+	r.snapshotter = snapshotter
+	return r
+}
+
+// Load rebuilds an aggregate from its latest snapshot and the events after it.
+// A snapshot of an outdated schema version is ignored.
+func (r *AggregateRepository[T]) Load(ctx context.Context, id uuid.UUID) (T, error) {
+
+	aggregate, stats, err := r.load(ctx, id)
+
+	if err != nil {
+		return aggregate, err
+	}
+
+	replayEvents.Observe(float64(stats.Events), r.aggregateType)
+	replaySeconds.Observe(stats.Duration.Seconds(), r.aggregateType)
+	r.replays.record(id, stats)
+
+	return aggregate, nil
+}
+
+// load rebuilds an aggregate without recording its replay
+func (r *AggregateRepository[T]) load(ctx context.Context, id uuid.UUID) (T, ReplayStats, error) {
+
+	aggregate, snapshotVersion := r.restoreSnapshot(ctx, id, 0, nil)
+
+	replayed := 0
+	started := time.Now()
+
+	err := r.events.StreamEventsByAggregateID(ctx, r.aggregateType, id, ReadOptions{After: snapshotVersion}, func(event events.Event) error {
+		replayed++
+		return aggregate.Apply(event)
+	})
+
+	var zero T
+
+	if err != nil {
+		return zero, ReplayStats{}, fmt.Errorf("failed to replay %s %s: %w", r.aggregateType, id, err)
+	}
+
+	if snapshotVersion == 0 && replayed == 0 {
+		return zero, ReplayStats{}, ErrAggregateNotFound
+	}
+
+	return aggregate, ReplayStats{Events: replayed, Duration: time.Since(started)}, nil
+}
+
+// PointInTime selects a past state of an aggregate: the state after the event
+// at Version when it is positive, otherwise the state as of Time
+type PointInTime struct {
+	Version int64
+	Time    time.Time
+}
+
+// AtVersion selects the state after the event with the given sequence number
+func AtVersion(version int64) PointInTime {
+	return PointInTime{Version: version}
+}
+
+// AtTime selects the state as of the given time
+func AtTime(t time.Time) PointInTime {
+	return PointInTime{Time: t}
+}
+
+// includes reports whether an event or snapshot at version, created at
+// createdAt, is part of the state at this point in time
+func (p PointInTime) includes(version int64, createdAt time.Time) bool {
+	if p.Version > 0 {
+		return version <= p.Version
+	}
+	return !createdAt.After(p.Time)
+}
+
+// errReplayDone stops a replay that has reached its point in time
+var errReplayDone = errors.New("replay reached point in time")
+
+// LoadAt rebuilds an aggregate as it was at a point in time. Only snapshots
+// taken at or before that point are used, and the replay stops at the first
+// later event. It returns ErrAggregateNotFound if the aggregate did not
+// exist yet.
+func (r *AggregateRepository[T]) LoadAt(ctx context.Context, id uuid.UUID, at PointInTime) (T, error) {
+
+	var zero T
+
+	if at.Version <= 0 && at.Time.IsZero() {
+		return zero, errors.New("point in time needs a version or a time")
+	}
+
+	before := int64(0)
+	if at.Version > 0 {
+		before = at.Version + 1
+	}
+
+	aggregate, snapshotVersion := r.restoreSnapshot(ctx, id, before, func(info SnapshotInfo) bool {
+		return at.includes(info.Version, info.CreatedAt)
+	})
+
+	opts := ReadOptions{After: snapshotVersion}
+
+	if at.Version > 0 {
+
+		// The snapshot is exactly the requested version
+		if snapshotVersion == at.Version {
+			return aggregate, nil
+		}
+
+		opts.Limit = int(at.Version - snapshotVersion)
+	}
+
+	replayed := 0
+
+	err := r.events.StreamEventsByAggregateID(ctx, r.aggregateType, id, opts, func(event events.Event) error {
+
+		if !at.includes(event.Sequence, event.CreatedAt) {
+			return errReplayDone
+		}
+
+		replayed++
+		return aggregate.Apply(event)
+	})
+
+	if err != nil && !errors.Is(err, errReplayDone) {
+		return zero, fmt.Errorf("failed to replay %s %s: %w", r.aggregateType, id, err)
+	}
+
+	if snapshotVersion == 0 && replayed == 0 {
+		return zero, ErrAggregateNotFound
+	}
+
+	return aggregate, nil
+}
+
+// maxSnapshotLookups bounds the snapshots inspected when restoring an
+// aggregate before falling back to a full replay
+const maxSnapshotLookups = 5
+
+// restoreSnapshot decodes the newest usable snapshot taken before the given
+// version (0 for any) and accepted by include, which may be nil. Snapshots of
+// another schema version or that fail to decode are skipped in favour of older
+// ones, so a bad deploy writing corrupt snapshots can be rolled back. It
+// returns an empty aggregate and version 0 if no snapshot is usable.
+func (r *AggregateRepository[T]) restoreSnapshot(ctx context.Context, id uuid.UUID, before int64, include func(SnapshotInfo) bool) (T, int64) {
+
+	for i := 0; i < maxSnapshotLookups; i++ {
+
+		snapshot, err := r.snapshots.GetSnapshot(ctx, r.aggregateType, id, before)
+
+		if errors.Is(err, ErrSnapshotUnreadable) && snapshot.Version > 0 {
+			snapshotDecodeFailures.Inc(r.aggregateType)
+			fmt.Printf("Warning: failed to read snapshot of %s %s, trying an older one: %v\n", r.aggregateType, id, err)
+			before = snapshot.Version
+			continue
+		}
+
+		if err != nil {
+
+			if !errors.Is(err, ErrSnapshotNotFound) {
+				fmt.Printf("Warning: failed to load snapshot for %s %s, replaying all events: %v\n", r.aggregateType, id, err)
+			}
+
+			break
+		}
+
+		before = snapshot.Version
+
+		if include != nil && !include(snapshot.SnapshotInfo) {
+			continue
+		}
+
+		if snapshot.SchemaVersion != r.schemaVersion {
+			snapshotsOutdated.Inc(r.aggregateType)
+			continue
+		}
+
+		aggregate := r.factory(id)
+
+		if err := json.Unmarshal(snapshot.Data, aggregate); err != nil {
+			snapshotDecodeFailures.Inc(r.aggregateType)
+			fmt.Printf("Warning: failed to decode snapshot of %s %s at version %d, trying an older one: %v\n", r.aggregateType, id, snapshot.Version, err)
+			continue
+		}
+
+		return aggregate, snapshot.Version
+	}
+
+	return r.factory(id), 0
+}
+
+// Save appends the aggregate's uncommitted events, failing with a
+// *ConcurrencyConflictError if the stream moved since the aggregate was loaded.
+// A snapshot is taken afterwards when the repository's policy asks for one,
+// in the background if the repository has an AsyncSnapshotter.
+func (r *AggregateRepository[T]) Save(ctx context.Context, aggregate T) error {
+
+	changes := aggregate.UncommittedEvents()
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	expectedVersion := aggregate.AggregateVersion() - int64(len(changes))
+
+	if _, err := r.events.AppendToStream(ctx, r.aggregateType, aggregate.AggregateID(), expectedVersion, changes); err != nil {
+		return err
+	}
+
+	aggregate.MarkCommitted()
+
+	// The events are stored, so a failed snapshot must not fail the save.
+	// The state is encoded now, before the caller can change the aggregate.
+	state, err := json.Marshal(aggregate)
+
+	if err != nil {
+		fmt.Printf("Warning: failed to encode %s %s for snapshot: %v\n", r.aggregateType, aggregate.AggregateID(), err)
+		return nil
+	}
+
+	snapshotCtx := SnapshotContext{
+
+		AggregateType: r.aggregateType,
+		AggregateID:   aggregate.AggregateID(),
+		Version:       aggregate.AggregateVersion(),
+		NewEvents:     changes,
+		StateSize:     len(state),
+		Replay:        r.replays.take(aggregate.AggregateID()),
+	}
+
+	if r.snapshotter == nil {
+		r.maybeSnapshot(ctx, snapshotCtx, state)
+		return nil
+	}
+
+	// The snapshot outlives the request, but keeps its values for tracing
+	jobCtx := context.WithoutCancel(ctx)
+
+	if !r.snapshotter.submit(aggregate.AggregateID(), func() { r.maybeSnapshot(jobCtx, snapshotCtx, state) }) {
+		snapshotsDropped.Inc(r.aggregateType)
+	}
+
+	return nil
+}
+
+// maybeSnapshot evaluates the snapshot policy against the latest snapshot
+// and saves state if it matches. Failures are logged and counted.
+func (r *AggregateRepository[T]) maybeSnapshot(ctx context.Context, snapshotCtx SnapshotContext, state []byte) {
+
+	info, err := r.snapshots.GetSnapshotInfo(ctx, r.aggregateType, snapshotCtx.AggregateID)
+
+	if err != nil && !errors.Is(err, ErrSnapshotNotFound) {
+		snapshotFailures.Inc(r.aggregateType)
+		fmt.Printf("Warning: failed to snapshot %s %s: %v\n", r.aggregateType, snapshotCtx.AggregateID, err)
+		return
+	}
+
+	// An outdated snapshot is as good as none
+	if info.SchemaVersion != r.schemaVersion {
+		info = SnapshotInfo{}
+	}
+
+	snapshotCtx.Snapshot = info
+
+	if !r.policy.ShouldSnapshot(snapshotCtx) {
+		return
+	}
+
+	err = r.snapshots.SaveSnapshot(ctx, r.aggregateType, snapshotCtx.AggregateID, snapshotCtx.Version, r.schemaVersion, json.RawMessage(state))
+
+	if err != nil {
+		snapshotFailures.Inc(r.aggregateType)
+		fmt.Printf("Warning: failed to snapshot %s %s: %v\n", r.aggregateType, snapshotCtx.AggregateID, err)
+		return
+	}
+
+	snapshotsSaved.Inc(r.aggregateType)
+}
+
+// RegenerateSnapshots rebuilds, batchSize aggregates at a time, every snapshot
+// written with an outdated schema version by replaying all of the aggregate's
+// events. It returns the number of snapshots rewritten; aggregates that fail to
+// load or save are logged and skipped.
+func (r *AggregateRepository[T]) RegenerateSnapshots(ctx context.Context, batchSize int) (int, error) {
+
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	regenerated := 0
+	after := uuid.Nil
+
+	for {
+
+		ids, err := r.snapshots.ListOutdatedSnapshots(ctx, r.aggregateType, r.schemaVersion, after, batchSize)
+
+		if err != nil {
+			return regenerated, fmt.Errorf("failed to list outdated %s snapshots: %w", r.aggregateType, err)
+		}
+
+		if len(ids) == 0 {
+			return regenerated, nil
+		}
+
+		for _, id := range ids {
+
+			if err := ctx.Err(); err != nil {
+				return regenerated, err
+			}
+
+			after = id
+
+			if err := r.regenerateSnapshot(ctx, id); err != nil {
+				snapshotFailures.Inc(r.aggregateType)
+				fmt.Printf("Warning: failed to regenerate snapshot for %s %s: %v\n", r.aggregateType, id, err)
+				continue
+			}
+
+			regenerated++
+			snapshotsSaved.Inc(r.aggregateType)
+		}
+	}
+}
+
+// regenerateSnapshot replaces the snapshot of one aggregate with its current state
+func (r *AggregateRepository[T]) regenerateSnapshot(ctx context.Context, id uuid.UUID) error {
+
+	aggregate, _, err := r.load(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	state, err := json.Marshal(aggregate)
+
+	if err != nil {
+		return err
+	}
+
+	return r.snapshots.SaveSnapshot(ctx, r.aggregateType, id, aggregate.AggregateVersion(), r.schemaVersion, json.RawMessage(state))
+}
+
+// maxTrackedReplays bounds the replay stats kept for aggregates that were
+// loaded but not saved yet
+const maxTrackedReplays = 10_000
+
+// replayTracker remembers how long each aggregate took to load until it is
+// saved, so snapshot policies can react to slow replays
+type replayTracker struct {
+	mu    sync.Mutex
+	stats map[uuid.UUID]ReplayStats
+}
+
+func newReplayTracker() *replayTracker {
+	return &replayTracker{stats: make(map[uuid.UUID]ReplayStats)}
+}
+
+// record stores the stats of a load. Aggregates loaded only for reading are
+// never taken, so the tracker starts over when it fills up.
+func (t *replayTracker) record(id uuid.UUID, stats ReplayStats) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.stats) >= maxTrackedReplays {
+		t.stats = make(map[uuid.UUID]ReplayStats)
+	}
+
+	t.stats[id] = stats
+}
+
+// take returns and forgets the stats of the last load of an aggregate
+func (t *replayTracker) take(id uuid.UUID) ReplayStats {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats[id]
+	delete(t.stats, id)
+
+	return stats
+}
@@ -1,19 +1,30 @@
 package repository
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/google/uuid"
 )
 
+// cachedFormatSeparator ends the format prefix of a cached stream
+const cachedFormatSeparator = ":"
+
 // CachedEventStore wraps an EventStore with Redis caching
 type CachedEventStore struct {
-	store EventStore
-	cache cache.RedisCache
-	ttl   time.Duration
+	store      EventStore
+	cache      cache.RedisCache
+	ttl        time.Duration
+	upcasters  *events.UpcasterRegistry
+	serializer events.Serializer
+	flights    *flightGroup
+	lockTTL    time.Duration
+	lockWait   time.Duration
 }
 
 // NewCachedEventStore creates a new cached event store
@@ -21,91 +32,422 @@ func NewCachedEventStore(store EventStore, redisCache cache.RedisCache, ttl time
 
 	return &CachedEventStore{
 
-		store: store,
-		cache: redisCache,
-		ttl:   ttl,
+		store:   store,
+		cache:   redisCache,
+		ttl:     ttl,
+		flights: newFlightGroup(),
 	}
 }
 
+// WithReloadLock lets only the replica holding a Redis lock, taken for ttl,
+// reload an expired stream. Other replicas serve the stale stream if the cache
+// still has one, or wait up to wait for the lock holder to cache it before
+// reading the store themselves.
+func (c *CachedEventStore) WithReloadLock(ttl, wait time.Duration) *CachedEventStore {
+
+	c.lockTTL = ttl
+	c.lockWait = wait
+
+	return c
+}
+
+// WithUpcasters upcasts cached streams on read, so entries cached before a
+// schema change still reach the domain in the latest version.
+// Uncached reads rely on the wrapped store to upcast.
+func (c *CachedEventStore) WithUpcasters(upcasters *events.UpcasterRegistry) *CachedEventStore {
+
+	c.upcasters = upcasters
+
+	return c
+}
+
+// WithSerializer changes the encoding of cached streams. Entries record their
+// format, so streams cached in another format are still read correctly.
+func (c *CachedEventStore) WithSerializer(serializer events.Serializer) *CachedEventStore {
+
+	c.serializer = serializer
+
+	return c
+}
+
 // AppendEvents implements EventStore.AppendEvents with caching
-func (c *CachedEventStore) AppendEvents(ctx context.Context, events []Event) error {
+func (c *CachedEventStore) AppendEvents(ctx context.Context, batch []events.Event) error {
 
 	// First append to the store
-	if err := c.store.AppendEvents(ctx, events); err != nil {
+	if err := c.store.AppendEvents(ctx, batch); err != nil {
 		return fmt.Errorf("failed to append events: %w", err)
 	}
 
-	// Group events by aggregate for batch invalidation
-	aggregateKeys := make([]string, 0, len(events))
+	// Extend each aggregate's cached stream with its events, in order
+	streams := make(map[streamKey][]events.Event)
+	keys := make([]streamKey, 0, len(batch))
+
+	for _, event := range batch {
+
+		key := streamKey{aggregateType: event.AggregateType, aggregateID: event.AggregateID}
 
-	for _, event := range events {
-		key := fmt.Sprintf("events:%s:%s", event.AggregateType, event.AggregateID)
-		aggregateKeys = append(aggregateKeys, key)
+		if _, ok := streams[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		streams[key] = append(streams[key], event)
 	}
 
-	// Batch delete cache entries
-	if err := c.cache.BatchDelete(ctx, aggregateKeys); err != nil {
-		fmt.Printf("Warning: failed to invalidate cache for %d aggregates: %v\n", len(aggregateKeys), err)
+	for _, key := range keys {
+		c.appendCachedStream(ctx, key.aggregateType, key.aggregateID, streams[key])
 	}
 
 	return nil
 }
 
-// GetEventsByAggregateID implements EventStore.GetEventsByAggregateID with caching
-func (c *CachedEventStore) GetEventsByAggregateID(ctx context.Context, aggregateType, aggregateID string) ([]Event, error) {
+// AppendToStream implements EventStore.AppendToStream with caching
+func (c *CachedEventStore) AppendToStream(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	expectedVersion int64,
+	newEvents []events.Event,
+) (int64, error) {
+
+	// Wrapped with %w so callers can still errors.As a *ConcurrencyConflictError
+	position, err := c.store.AppendToStream(ctx, aggregateType, aggregateID, expectedVersion, newEvents)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to stream: %w", err)
+	}
+
+	c.appendCachedStream(ctx, aggregateType, aggregateID, newEvents)
+
+	return position, nil
+}
+
+// streamKey identifies an aggregate's event stream
+type streamKey struct {
+	aggregateType string
+	aggregateID   uuid.UUID
+}
+
+// appendCachedStream appends newly stored events to the cached stream of their
+// aggregate. The cache only accepts them if it holds every earlier event, so a
+// gap drops the cached stream and the next read reloads it from the store.
+func (c *CachedEventStore) appendCachedStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID, stream []events.Event) {
+
+	if len(stream) == 0 {
+		return
+	}
+
+	key := fmt.Sprintf("stream:%s:%s", aggregateType, aggregateID)
+
+	entries, err := c.encodeCachedEvents(stream)
+
+	if err == nil && stream[0].Sequence > 0 {
+		_, err = c.cache.AppendEventList(ctx, aggregateType, aggregateID.String(), stream[0].Sequence-1, entries)
+	} else if err == nil {
+		// Without sequence numbers the position in the stream is unknown
+		err = c.cache.SetEventList(ctx, aggregateType, aggregateID.String(), nil)
+	}
+
+	if err != nil {
+		fmt.Printf("Warning: failed to update cache for %s: %v\n", key, err)
+	}
+}
+
+// lockPollInterval is how often a replica waiting for another one to cache
+// a stream checks the cache
+const lockPollInterval = time.Millisecond * 25
+
+// GetEventsByAggregateID implements EventStore.GetEventsByAggregateID with caching.
+// Concurrent misses of the same stream in this process share a single load.
+func (c *CachedEventStore) GetEventsByAggregateID(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error) {
+
+	return c.loadStream(ctx, aggregateType, aggregateID)
+}
+
+// loadStream returns the whole upcast stream of an aggregate from the cache,
+// or reloads it once per process and, with a reload lock, once across replicas
+func (c *CachedEventStore) loadStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error) {
+
+	key := fmt.Sprintf("stream:%s:%s", aggregateType, aggregateID)
+
 	// Try to get from cache first
-	cached, err := c.cache.GetEventStream(ctx, aggregateType, aggregateID)
+	stream, fresh := c.cachedStream(ctx, aggregateType, aggregateID)
 
-	if err == nil {
+	if fresh {
+		eventCacheRequests.Inc(aggregateType, "hit")
+		return c.upcasters.UpcastAll(stream)
+	}
 
-		var events []Event
+	// The load runs under the first caller's context
+	result, shared, err := c.flights.do(key, func() ([]events.Event, error) {
+		return c.reload(ctx, aggregateType, aggregateID, stream)
+	})
 
-		if err := json.Unmarshal(cached, &events); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal cached events: %w", err)
-		}
+	if shared {
+		eventCacheRequests.Inc(aggregateType, "coalesced")
+	}
+
+	return result, err
+}
+
+// reload fetches a stream that is missing or stale in the cache and caches it.
+// With a reload lock, a replica that does not get the lock serves the stale
+// stream, or waits for the lock holder to cache the stream.
+func (c *CachedEventStore) reload(ctx context.Context, aggregateType string, aggregateID uuid.UUID, stale []events.Event) ([]events.Event, error) {
+
+	key := fmt.Sprintf("stream:%s:%s", aggregateType, aggregateID)
+
+	if c.lockTTL > 0 {
+
+		lockKey := "lock:" + key
+		token := uuid.New().String()
+
+		locked, err := c.cache.TryLock(ctx, lockKey, token, c.lockTTL)
+
+		switch {
+
+		case err != nil:
+			fmt.Printf("Warning: failed to lock %s, reloading without lock: %v\n", key, err)
 
-		return events, nil
+		case locked:
+			defer func() {
+				if err := c.cache.Unlock(context.WithoutCancel(ctx), lockKey, token); err != nil {
+					fmt.Printf("Warning: failed to unlock %s: %v\n", key, err)
+				}
+			}()
+
+		case stale != nil:
+			eventCacheRequests.Inc(aggregateType, "stale")
+			return c.upcasters.UpcastAll(stale)
+
+		default:
+			if stream, ok := c.awaitCachedStream(ctx, aggregateType, aggregateID); ok {
+				eventCacheRequests.Inc(aggregateType, "waited")
+				return c.upcasters.UpcastAll(stream)
+			}
+		}
 	}
 
-	// If not in cache, get from store
-	events, err := c.store.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	eventCacheRequests.Inc(aggregateType, "miss")
+
+	result, err := c.store.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events from store: %w", err)
 	}
 
 	// Cache the result
-	data, err := json.Marshal(events)
+	entries, err := c.encodeCachedEvents(result)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal events: %w", err)
 	}
 
-	// Use batch operation for potential future optimization
-	streams := map[string]map[string][]byte{
+	// Events appended since the read may already be cached, so a longer list is kept
+	if _, err := c.cache.FillEventList(ctx, aggregateType, aggregateID.String(), entries); err != nil {
 
-		aggregateType: {
-			aggregateID: data,
-		},
+		fmt.Printf("Warning: failed to cache events: %v\n", err)
 	}
 
-	if err := c.cache.BatchSetEventStreams(ctx, streams); err != nil {
+	return result, nil
+}
 
-		fmt.Printf("Warning: failed to cache events: %v\n", err)
+// cachedStream reads a stream from the cache and reports whether it is fresh.
+// A stale stream is returned with fresh false; a missing or unreadable one is nil.
+func (c *CachedEventStore) cachedStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, bool) {
+
+	entries, fresh, err := c.cache.GetEventList(ctx, aggregateType, aggregateID.String())
+
+	if err != nil {
+
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			fmt.Printf("Warning: failed to read cached events for %s %s: %v\n", aggregateType, aggregateID, err)
+		}
+
+		return nil, false
 	}
 
-	return events, nil
+	stream, err := decodeCachedEvents(entries)
+
+	if err != nil {
+		fmt.Printf("Warning: failed to decode cached events for %s %s, reloading: %v\n", aggregateType, aggregateID, err)
+		return nil, false
+	}
+
+	return stream, fresh
+}
+
+// awaitCachedStream polls the cache until another replica has cached the
+// stream or the lock wait is over
+func (c *CachedEventStore) awaitCachedStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, bool) {
+
+	deadline := time.Now().Add(c.lockWait)
+
+	for time.Now().Before(deadline) {
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(lockPollInterval):
+		}
+
+		if stream, fresh := c.cachedStream(ctx, aggregateType, aggregateID); fresh {
+			return stream, true
+		}
+	}
+
+	return nil, false
 }
 
 // GetEventsByType implements EventStore.GetEventsByType
-func (c *CachedEventStore) GetEventsByType(ctx context.Context, eventType string) ([]Event, error) {
+func (c *CachedEventStore) GetEventsByType(ctx context.Context, eventType events.EventType) ([]events.Event, error) {
 
 	// This operation is not cached as it's not frequently used
 	return c.store.GetEventsByType(ctx, eventType)
 }
 
+// GetEventsByCorrelationID implements EventStore.GetEventsByCorrelationID
+func (c *CachedEventStore) GetEventsByCorrelationID(ctx context.Context, correlationID string) ([]events.Event, error) {
+
+	return c.store.GetEventsByCorrelationID(ctx, correlationID)
+}
+
+// QueryEvents implements EventStore.QueryEvents
+func (c *CachedEventStore) QueryEvents(ctx context.Context, query EventQuery) ([]events.Event, error) {
+
+	// Ad hoc queries rarely repeat, so they are not cached
+	return c.store.QueryEvents(ctx, query)
+}
+
 // GetEventsAfterSequence implements EventStore.GetEventsAfterSequence
-func (c *CachedEventStore) GetEventsAfterSequence(ctx context.Context, sequence int64) ([]Event, error) {
+func (c *CachedEventStore) GetEventsAfterSequence(ctx context.Context, sequence int64) ([]events.Event, error) {
 
 	return c.store.GetEventsAfterSequence(ctx, sequence)
 }
+
+// ReadAll implements EventStore.ReadAll
+func (c *CachedEventStore) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error) {
+
+	// The global log is read sequentially by consumers, caching would not help
+	return c.store.ReadAll(ctx, fromPosition, limit)
+}
+
+// StreamEventsByAggregateID implements EventStore.StreamEventsByAggregateID
+// with caching. The stream is loaded like GetEventsByAggregateID, so misses
+// are coalesced, and delivered from opts.After on, up to opts.Limit events.
+func (c *CachedEventStore) StreamEventsByAggregateID(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	opts ReadOptions,
+	handler EventHandler,
+) error {
+
+	stream, err := c.loadStream(ctx, aggregateType, aggregateID)
+
+	if err != nil {
+		return err
+	}
+
+	return deliverStream(stream, opts, handler)
+}
+
+// deliverStream hands the events of a whole aggregate stream after opts.After
+// to handler, stopping after opts.Limit events if it is set
+func deliverStream(stream []events.Event, opts ReadOptions, handler EventHandler) error {
+
+	delivered := 0
+
+	for _, event := range stream {
+
+		if event.Sequence <= opts.After {
+			continue
+		}
+
+		if opts.Limit > 0 && delivered >= opts.Limit {
+			return nil
+		}
+
+		if err := handler(event); err != nil {
+			return err
+		}
+
+		delivered++
+	}
+
+	return nil
+}
+
+// StreamEventsByType implements EventStore.StreamEventsByType
+func (c *CachedEventStore) StreamEventsByType(
+	ctx context.Context,
+	eventType events.EventType,
+	opts ReadOptions,
+	handler EventHandler,
+) error {
+
+	return c.store.StreamEventsByType(ctx, eventType, opts, handler)
+}
+
+// StreamEventsAfterSequence implements EventStore.StreamEventsAfterSequence
+func (c *CachedEventStore) StreamEventsAfterSequence(
+	ctx context.Context,
+	sequence int64,
+	opts ReadOptions,
+	handler EventHandler,
+) error {
+
+	return c.store.StreamEventsAfterSequence(ctx, sequence, opts, handler)
+}
+
+// encodeCachedEvents serializes events as cache list entries, each prefixed
+// with its format
+func (c *CachedEventStore) encodeCachedEvents(stream []events.Event) ([][]byte, error) {
+
+	serializer := c.serializer
+
+	if serializer == nil {
+		serializer, _ = events.SerializerFor(events.FormatJSON)
+	}
+
+	prefix := string(serializer.Format()) + cachedFormatSeparator
+	entries := make([][]byte, len(stream))
+
+	for i, event := range stream {
+
+		data, err := serializer.Marshal(event)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries[i] = append([]byte(prefix), data...)
+	}
+
+	return entries, nil
+}
+
+// decodeCachedEvents reads cached list entries
+func decodeCachedEvents(entries [][]byte) ([]events.Event, error) {
+
+	stream := make([]events.Event, len(entries))
+
+	for i, entry := range entries {
+
+		format, body, found := bytes.Cut(entry, []byte(cachedFormatSeparator))
+
+		if !found {
+			return nil, fmt.Errorf("cached event %d has no format", i)
+		}
+
+		serializer, err := events.SerializerFor(events.Format(format))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := serializer.Unmarshal(body, &stream[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return stream, nil
+}
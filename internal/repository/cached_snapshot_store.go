@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/google/uuid"
+)
+
+// CachedSnapshotStore wraps a SnapshotStore with a Redis cache of the latest
+// snapshot of each aggregate. Entries are versioned by the snapshot's aggregate
+// version: a save drops older entries, and a snapshot read before a newer one
+// was saved is never written back.
+type CachedSnapshotStore struct {
+	store SnapshotStore
+	cache cache.RedisCache
+}
+
+// NewCachedSnapshotStore creates a new cached snapshot store
+func NewCachedSnapshotStore(store SnapshotStore, redisCache cache.RedisCache) *CachedSnapshotStore {
+
+	return &CachedSnapshotStore{
+
+		store: store,
+		cache: redisCache,
+	}
+}
+
+// snapshotCacheKey returns the cache key of an aggregate's latest snapshot
+func snapshotCacheKey(aggregateType string, aggregateID uuid.UUID) string {
+	return fmt.Sprintf("snapshots:%s:%s", aggregateType, aggregateID)
+}
+
+// SaveSnapshot implements SnapshotStore.SaveSnapshot and invalidates older cached snapshots
+func (c *CachedSnapshotStore) SaveSnapshot(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	version int64,
+	schemaVersion int,
+	data interface{},
+) error {
+
+	if err := c.store.SaveSnapshot(ctx, aggregateType, aggregateID, version, schemaVersion, data); err != nil {
+		return err
+	}
+
+	key := snapshotCacheKey(aggregateType, aggregateID)
+
+	if err := c.cache.InvalidateVersioned(ctx, key, version); err != nil {
+		fmt.Printf("Warning: failed to invalidate cache for %s: %v\n", key, err)
+	}
+
+	return nil
+}
+
+// GetSnapshot implements SnapshotStore.GetSnapshot. Only the latest snapshot
+// is cached; lookups of older ones go to the wrapped store.
+func (c *CachedSnapshotStore) GetSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, before int64) (Snapshot, error) {
+
+	if before > 0 {
+		return c.store.GetSnapshot(ctx, aggregateType, aggregateID, before)
+	}
+
+	if snapshot, ok := c.cached(ctx, aggregateType, aggregateID); ok {
+		return snapshot, nil
+	}
+
+	snapshot, err := c.store.GetSnapshot(ctx, aggregateType, aggregateID, 0)
+
+	if err != nil {
+		return snapshot, err
+	}
+
+	key := snapshotCacheKey(aggregateType, aggregateID)
+
+	// A snapshot saved since the read has invalidated this version, so the
+	// write back is skipped rather than caching a superseded snapshot
+	if data, err := json.Marshal(snapshot); err != nil {
+		fmt.Printf("Warning: failed to encode snapshot for %s: %v\n", key, err)
+	} else if _, err := c.cache.SetVersioned(ctx, key, snapshot.Version, data); err != nil {
+		fmt.Printf("Warning: failed to cache snapshot for %s: %v\n", key, err)
+	}
+
+	return snapshot, nil
+}
+
+// GetSnapshotInfo implements SnapshotStore.GetSnapshotInfo
+func (c *CachedSnapshotStore) GetSnapshotInfo(ctx context.Context, aggregateType string, aggregateID uuid.UUID) (SnapshotInfo, error) {
+
+	if snapshot, ok := c.cached(ctx, aggregateType, aggregateID); ok {
+		return snapshot.SnapshotInfo, nil
+	}
+
+	return c.store.GetSnapshotInfo(ctx, aggregateType, aggregateID)
+}
+
+// ListOutdatedSnapshots implements SnapshotStore.ListOutdatedSnapshots
+func (c *CachedSnapshotStore) ListOutdatedSnapshots(ctx context.Context, aggregateType string, schemaVersion int, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+
+	return c.store.ListOutdatedSnapshots(ctx, aggregateType, schemaVersion, after, limit)
+}
+
+// PruneSnapshots implements SnapshotStore.PruneSnapshots. Pruning keeps the
+// latest snapshots, so cached entries stay valid.
+func (c *CachedSnapshotStore) PruneSnapshots(ctx context.Context, aggregateType string, keep int) (int64, error) {
+
+	return c.store.PruneSnapshots(ctx, aggregateType, keep)
+}
+
+// cached returns the cached latest snapshot of an aggregate, if any
+func (c *CachedSnapshotStore) cached(ctx context.Context, aggregateType string, aggregateID uuid.UUID) (Snapshot, bool) {
+
+	var snapshot Snapshot
+
+	key := snapshotCacheKey(aggregateType, aggregateID)
+
+	data, err := c.cache.Get(ctx, key)
+
+	if err != nil {
+
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			fmt.Printf("Warning: failed to read cached snapshot for %s: %v\n", key, err)
+		}
+
+		return snapshot, false
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		fmt.Printf("Warning: failed to decode cached snapshot for %s: %v\n", key, err)
+
+		// Drop the entry so the next read refills it from the store
+		if err := c.cache.Delete(ctx, key); err != nil {
+			fmt.Printf("Warning: failed to drop undecodable snapshot for %s: %v\n", key, err)
+		}
+
+		return snapshot, false
+	}
+
+	return snapshot, true
+}
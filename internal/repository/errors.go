@@ -1,10 +1,56 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
 
 var (
 	// ErrSnapshotNotFound is returned when a snapshot is not found
 	ErrSnapshotNotFound = errors.New("snapshot not found")
+	// ErrSnapshotUnreadable is returned with the snapshot's version when its payload cannot be read
+	ErrSnapshotUnreadable = errors.New("snapshot payload cannot be read")
 	// ErrProjectionNotFound is returned when a projection is not found
 	ErrProjectionNotFound = errors.New("projection not found")
+	// ErrCheckpointMoved is returned when another runner advanced a projection's checkpoint
+	ErrCheckpointMoved = errors.New("projection checkpoint moved")
+	// ErrLeaseLost is returned when an outbox lease expired and was taken by another publisher
+	ErrLeaseLost = errors.New("outbox lease lost")
+	// ErrReservationLost is returned when an idempotency key reservation went stale and was taken over
+	ErrReservationLost = errors.New("idempotency key reservation lost")
+	// ErrOrderViewNotFound is returned when an order is not in the read model
+	ErrOrderViewNotFound = errors.New("order view not found")
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or does not match the query
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidEventQuery is returned when an event query has contradictory filters
+	ErrInvalidEventQuery = errors.New("invalid event query")
+	// ErrAggregateNotFound is returned when an aggregate has no snapshot and no events
+	ErrAggregateNotFound = errors.New("aggregate not found")
+	// ErrDuplicateEventID is returned when an append reuses event IDs of different stored events
+	ErrDuplicateEventID = errors.New("duplicate event id")
+	// ErrConcurrencyConflict is returned when a stream's version does not match the expected version
+	ErrConcurrencyConflict = errors.New("concurrency conflict")
 )
+
+// ConcurrencyConflictError carries the details of a failed expected-version check.
+// It matches ErrConcurrencyConflict with errors.Is.
+type ConcurrencyConflictError struct {
+	AggregateType   string
+	AggregateID     uuid.UUID
+	ExpectedVersion int64
+	ActualVersion   int64
+}
+
+func (e *ConcurrencyConflictError) Error() string {
+	return fmt.Sprintf(
+		"concurrency conflict on %s %s: expected version %d, actual version %d",
+		e.AggregateType, e.AggregateID, e.ExpectedVersion, e.ActualVersion,
+	)
+}
+
+// Is reports whether target is ErrConcurrencyConflict
+func (e *ConcurrencyConflictError) Is(target error) bool {
+	return target == ErrConcurrencyConflict
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/google/uuid"
+)
+
+// EventQuery selects events across streams. Zero values leave a filter unset,
+// and filters on the same field match any of their values. Results are ordered
+// by global position.
+//
+// Queries are built by chaining, e.g.
+//
+//	NewEventQuery().
+//		WithEventTypes(events.OrderCancelledEventType).
+//		CreatedBetween(from, to).
+//		WithLimit(100)
+type EventQuery struct {
+	AggregateTypes []string
+	AggregateIDs   []uuid.UUID
+	EventTypes     []events.EventType
+	// From and To bound the event creation time, inclusive and exclusive
+	From time.Time
+	To   time.Time
+	// Metadata requires each key to be present with an equal JSON value
+	Metadata map[string]interface{}
+
+	Descending bool
+	// Limit caps the number of events returned, 0 means no limit
+	Limit int
+	// After resumes past the global position of the last event of the
+	// previous page, in the direction of the query
+	After int64
+}
+
+// NewEventQuery creates a query matching every event
+func NewEventQuery() EventQuery {
+	return EventQuery{}
+}
+
+// WithAggregateTypes restricts the query to streams of the given aggregate types
+func (q EventQuery) WithAggregateTypes(aggregateTypes ...string) EventQuery {
+	q.AggregateTypes = append(append([]string(nil), q.AggregateTypes...), aggregateTypes...)
+	return q
+}
+
+// WithAggregateIDs restricts the query to the given streams
+func (q EventQuery) WithAggregateIDs(aggregateIDs ...uuid.UUID) EventQuery {
+	q.AggregateIDs = append(append([]uuid.UUID(nil), q.AggregateIDs...), aggregateIDs...)
+	return q
+}
+
+// WithEventTypes restricts the query to the given event types
+func (q EventQuery) WithEventTypes(eventTypes ...events.EventType) EventQuery {
+	q.EventTypes = append(append([]events.EventType(nil), q.EventTypes...), eventTypes...)
+	return q
+}
+
+// CreatedBetween restricts the query to events created in [from, to).
+// Either bound may be zero to leave it open.
+func (q EventQuery) CreatedBetween(from, to time.Time) EventQuery {
+	q.From, q.To = from, to
+	return q
+}
+
+// WithMetadata requires a metadata key to hold value
+func (q EventQuery) WithMetadata(key string, value interface{}) EventQuery {
+	metadata := make(map[string]interface{}, len(q.Metadata)+1)
+	for k, v := range q.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+
+	q.Metadata = metadata
+	return q
+}
+
+// OrderDescending returns the newest events first
+func (q EventQuery) OrderDescending() EventQuery {
+	q.Descending = true
+	return q
+}
+
+// WithLimit caps the number of events returned
+func (q EventQuery) WithLimit(limit int) EventQuery {
+	q.Limit = limit
+	return q
+}
+
+// StartAfter resumes the query past a global position
+func (q EventQuery) StartAfter(position int64) EventQuery {
+	q.After = position
+	return q
+}
+
+// Validate checks the query for contradictory filters
+func (q EventQuery) Validate() error {
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from %s is not before to %s", ErrInvalidEventQuery, q.From, q.To)
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("%w: negative limit %d", ErrInvalidEventQuery, q.Limit)
+	}
+	if q.After < 0 {
+		return fmt.Errorf("%w: negative position %d", ErrInvalidEventQuery, q.After)
+	}
+
+	return nil
+}
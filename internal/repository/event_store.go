@@ -2,43 +2,192 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/HarshavardhanK/espm/internal/events"
 	"github.com/google/uuid"
 )
 
+// Expected version sentinels for AppendToStream. Any positive value
+// requires the stream to be at exactly that version.
+const (
+	// ExpectedVersionAny skips the version check
+	ExpectedVersionAny int64 = -1
+	// ExpectedVersionNoStream requires that the stream has no events yet
+	ExpectedVersionNoStream int64 = 0
+)
+
+// VersionMatches reports whether a stream at actual satisfies the expected version
+func VersionMatches(expected, actual int64) bool {
+	return expected == ExpectedVersionAny || expected == actual
+}
+
+// DefaultBatchSize is the page size used by streaming reads when none is given
+const DefaultBatchSize = 500
+
+// ReadOptions controls how streaming reads page through the store
+type ReadOptions struct {
+	// BatchSize is the number of events fetched per round trip
+	BatchSize int
+	// Limit caps the total number of events delivered, 0 means no limit
+	Limit int
+	// After resumes the read past a cursor: the sequence number for
+	// aggregate reads, the global position for type reads
+	After int64
+}
+
+// EventHandler receives events from a streaming read in order.
+// Returning an error stops the read and the error is passed back to the caller.
+type EventHandler func(event events.Event) error
+
 // EventStore defines the interface for event storage
 type EventStore interface {
-	// AppendEvents appends new events to the store
+	// AppendEvents appends new events to the store and sets their positions.
+	// Appending events whose IDs are all stored already is a no-op that reports
+	// the original positions, so retries are safe. An event whose sequence number
+	// is already taken in its stream fails the append with a *ConcurrencyConflictError.
 	AppendEvents(ctx context.Context, events []events.Event) error
 
+	// AppendToStream appends events to a single aggregate stream if the stream
+	// is at expectedVersion, assigning sequence numbers after the current version.
+	// It returns the global position of the last appended event, or a
+	// *ConcurrencyConflictError when the check fails. Like AppendEvents, a retry
+	// of an append that already committed returns its original position.
+	AppendToStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID, expectedVersion int64, events []events.Event) (int64, error)
+
+	// ReadAll retrieves up to limit events across all streams with a global
+	// position greater than fromPosition, in commit order
+	ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error)
+
 	// GetEventsByAggregateID retrieves all events for a specific aggregate
 	GetEventsByAggregateID(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error)
 
 	// GetEventsByType retrieves all events of a specific type
 	GetEventsByType(ctx context.Context, eventType events.EventType) ([]events.Event, error)
 
+	// GetEventsByCorrelationID retrieves every event recorded for one correlation
+	// ID across all streams, in commit order
+	GetEventsByCorrelationID(ctx context.Context, correlationID string) ([]events.Event, error)
+
+	// QueryEvents retrieves the events matching a query across all streams
+	QueryEvents(ctx context.Context, query EventQuery) ([]events.Event, error)
+
 	// GetEventsAfterSequence retrieves all events after a specific sequence number
 	GetEventsAfterSequence(ctx context.Context, sequence int64) ([]events.Event, error)
+
+	// StreamEventsByAggregateID delivers the events of an aggregate to handler page by page
+	StreamEventsByAggregateID(ctx context.Context, aggregateType string, aggregateID uuid.UUID, opts ReadOptions, handler EventHandler) error
+
+	// StreamEventsByType delivers all events of a type to handler page by page, in commit order
+	StreamEventsByType(ctx context.Context, eventType events.EventType, opts ReadOptions, handler EventHandler) error
+
+	// StreamEventsAfterSequence delivers all events after a sequence number to handler page by page
+	StreamEventsAfterSequence(ctx context.Context, sequence int64, opts ReadOptions, handler EventHandler) error
+}
+
+// BatchHandler receives the events of one read of a subscription in commit order.
+// Returning an error ends the subscription and the error is passed back to the caller.
+type BatchHandler func(batch []events.Event) error
+
+// EventSubscriber delivers events to a handler as they are committed
+type EventSubscriber interface {
+	// Subscribe catches up from fromPosition and then delivers new events in
+	// commit order until ctx is cancelled or handler returns an error.
+	// Delivery is at-least-once: a restarted subscription may repeat events
+	// after the caller's last checkpoint.
+	Subscribe(ctx context.Context, fromPosition int64, handler EventHandler) error
+
+	// SubscribeBatches is like Subscribe but hands over the events read
+	// together. Each time the subscription has caught up, handler is called
+	// with an empty batch.
+	SubscribeBatches(ctx context.Context, fromPosition int64, handler BatchHandler) error
+}
+
+// SnapshotInfo describes a stored snapshot without its payload
+type SnapshotInfo struct {
+	Version int64
+	// SchemaVersion is the version of the aggregate state layout the snapshot holds
+	SchemaVersion int
+	CreatedAt     time.Time
+}
+
+// Snapshot is a stored snapshot with its encoded aggregate state
+type Snapshot struct {
+	SnapshotInfo
+	Data []byte
 }
 
 // SnapshotStore defines the interface for aggregate snapshots
 type SnapshotStore interface {
-	// SaveSnapshot saves a snapshot of an aggregate
-	SaveSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, version int, data []byte) error
+	// SaveSnapshot adds a snapshot of an aggregate at the given version, recording
+	// the schema version of the state in data. Older snapshots are kept until pruned.
+	SaveSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, version int64, schemaVersion int, data interface{}) error
+
+	// GetSnapshot retrieves the newest snapshot of an aggregate taken before the
+	// given version, or the newest of all if before is 0. A snapshot whose payload
+	// cannot be read fails with ErrSnapshotUnreadable and still carries its info.
+	GetSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, before int64) (Snapshot, error)
+
+	// GetSnapshotInfo retrieves the version and age of the latest snapshot for an aggregate
+	GetSnapshotInfo(ctx context.Context, aggregateType string, aggregateID uuid.UUID) (SnapshotInfo, error)
+
+	// ListOutdatedSnapshots retrieves up to limit IDs of aggregates, ordered and
+	// starting after the given ID, whose latest snapshot is not at schemaVersion
+	ListOutdatedSnapshots(ctx context.Context, aggregateType string, schemaVersion int, after uuid.UUID, limit int) ([]uuid.UUID, error)
+
+	// PruneSnapshots deletes all but the newest keep snapshots of each aggregate
+	// of a type and returns the number of snapshots deleted
+	PruneSnapshots(ctx context.Context, aggregateType string, keep int) (int64, error)
+}
 
-	// GetLatestSnapshot retrieves the latest snapshot for an aggregate
-	GetLatestSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]byte, int, error)
+// ProjectionStatus is the lifecycle state reported by a projection
+type ProjectionStatus string
+
+const (
+	ProjectionRunning    ProjectionStatus = "running"
+	ProjectionStalled    ProjectionStatus = "stalled"
+	ProjectionRebuilding ProjectionStatus = "rebuilding"
+	ProjectionFailed     ProjectionStatus = "failed"
+)
+
+// ProjectionInfo describes the progress of a projection
+type ProjectionInfo struct {
+	Name       string           `json:"name"`
+	Checkpoint int64            `json:"checkpoint"`
+	Status     ProjectionStatus `json:"status"`
+	LastError  string           `json:"lastError,omitempty"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+}
+
+// Tx is the transaction a projection batch or reset runs in. Read models
+// write through it so their changes commit together with the checkpoint.
+type Tx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
+// ApplyFunc writes the read model changes for a single event inside the checkpoint transaction
+type ApplyFunc func(ctx context.Context, tx Tx, event events.Event) error
+
+// ResetFunc clears a read model inside the transaction that rewinds its checkpoint
+type ResetFunc func(ctx context.Context, tx Tx) error
+
 // ProjectionStore defines the interface for projection state storage
 type ProjectionStore interface {
 	// SaveProjectionState saves the state of a projection
-	SaveProjectionState(ctx context.Context, projectionType string, data []byte) error
+	SaveProjectionState(ctx context.Context, projectionName string, state interface{}) error
 
-	// GetProjectionState retrieves the state of a projection
-	GetProjectionState(ctx context.Context, projectionType string) ([]byte, error)
+	// GetProjectionState decodes the state of a projection into state
+	GetProjectionState(ctx context.Context, projectionName string, state interface{}) error
+
+	// GetCheckpoint retrieves the global position a projection has processed up to
+	GetCheckpoint(ctx context.Context, projectionName string) (int64, error)
 
 	// UpdateProjectionStatus updates the status of a projection
-	UpdateProjectionStatus(ctx context.Context, projectionType string, status string) error
+	UpdateProjectionStatus(ctx context.Context, projectionName string, status ProjectionStatus, lastError string) error
+
+	// ListProjections retrieves the progress of all projections
+	ListProjections(ctx context.Context) ([]ProjectionInfo, error)
 }
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the stored outcome of a request made with an idempotency key
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	// Token identifies the reservation. Only its holder can complete or release the key.
+	Token string
+	// Completed is false while the original request is still running
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore defines the interface for idempotency key storage
+type IdempotencyStore interface {
+	// Reserve claims a key for a request for the given window. If the key is
+	// already held and unexpired it returns the existing record and false.
+	Reserve(ctx context.Context, key, requestHash string, window time.Duration) (IdempotencyRecord, bool, error)
+
+	// Complete stores the response of a reserved key for replay. It fails with
+	// ErrReservationLost if the reservation identified by token was taken over.
+	Complete(ctx context.Context, key, token string, statusCode int, contentType string, response []byte) error
+
+	// Release frees a reserved key so the request can be retried
+	Release(ctx context.Context, key, token string) error
+
+	// PurgeExpired deletes keys whose window has passed
+	PurgeExpired(ctx context.Context) (int64, error)
+}
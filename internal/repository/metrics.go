@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/HarshavardhanK/espm/pkg/telemetry"
+)
+
+// Aggregate load, snapshot and cache metrics, labelled by aggregate type
+var (
+	replayEvents = telemetry.DefaultRegistry.NewHistogram(
+		"espm_aggregate_replay_events",
+		"Number of events replayed on top of the snapshot when loading an aggregate.",
+		telemetry.ExponentialBuckets(1, 2, 12),
+		"aggregate_type",
+	)
+	replaySeconds = telemetry.DefaultRegistry.NewHistogram(
+		"espm_aggregate_replay_seconds",
+		"Time spent replaying events when loading an aggregate.",
+		telemetry.ExponentialBuckets(0.001, 2, 14),
+		"aggregate_type",
+	)
+	snapshotsSaved = telemetry.DefaultRegistry.NewCounter(
+		"espm_snapshots_saved_total",
+		"Snapshots written by snapshot policies or regeneration.",
+		"aggregate_type",
+	)
+	snapshotFailures = telemetry.DefaultRegistry.NewCounter(
+		"espm_snapshot_failures_total",
+		"Snapshot attempts that failed.",
+		"aggregate_type",
+	)
+	snapshotsDropped = telemetry.DefaultRegistry.NewCounter(
+		"espm_snapshots_dropped_total",
+		"Snapshots skipped because the background queue was full.",
+		"aggregate_type",
+	)
+	snapshotsOutdated = telemetry.DefaultRegistry.NewCounter(
+		"espm_snapshots_outdated_total",
+		"Snapshots ignored on load because of an outdated schema version.",
+		"aggregate_type",
+	)
+	snapshotDecodeFailures = telemetry.DefaultRegistry.NewCounter(
+		"espm_snapshot_decode_failures_total",
+		"Snapshots skipped on load because they could not be decoded.",
+		"aggregate_type",
+	)
+	eventCacheRequests = telemetry.DefaultRegistry.NewCounter(
+		"espm_event_cache_requests_total",
+		"Cached event stream reads by result: hit, miss, coalesced, stale or waited.",
+		"aggregate_type", "result",
+	)
+)
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSortField is a column order listings can be sorted by
+type OrderSortField string
+
+const (
+	SortByCreatedAt   OrderSortField = "createdAt"
+	SortByUpdatedAt   OrderSortField = "updatedAt"
+	SortByTotalAmount OrderSortField = "totalAmount"
+)
+
+// OrderViewItem is a product line in the order read model
+type OrderViewItem struct {
+	ProductID uuid.UUID `json:"productId"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unitPrice"`
+}
+
+// OrderView is the denormalized order read model maintained by projections
+type OrderView struct {
+	ID          uuid.UUID       `json:"id"`
+	CustomerID  uuid.UUID       `json:"customerId"`
+	Status      string          `json:"status"`
+	Items       []OrderViewItem `json:"items"`
+	ItemCount   int             `json:"itemCount"`
+	TotalAmount float64         `json:"totalAmount"`
+	Version     int64           `json:"version"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// OrderQuery filters and pages an order listing. Zero values leave a filter unset.
+type OrderQuery struct {
+	CustomerID uuid.UUID
+	Status     string
+	// From and To bound the order creation time, inclusive and exclusive
+	From time.Time
+	To   time.Time
+	// MinTotal only returns orders with a total of at least this amount
+	MinTotal *float64
+
+	SortBy     OrderSortField
+	Descending bool
+
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// OrderPage is a page of an order listing
+type OrderPage struct {
+	Orders     []OrderView `json:"orders"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// OrderViewStore reads the order read model
+type OrderViewStore interface {
+	// GetOrder retrieves a single order view
+	GetOrder(ctx context.Context, id uuid.UUID) (OrderView, error)
+
+	// ListOrders retrieves a page of orders matching the query
+	ListOrders(ctx context.Context, query OrderQuery) (OrderPage, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+)
+
+// OutboxMessage is an appended event waiting to be published
+type OutboxMessage struct {
+	ID       int64
+	Attempts int
+	Event    events.Event
+}
+
+// OutboxStore defines the interface for draining the transactional outbox.
+// Messages are leased per aggregate so that concurrent publishers never send
+// the same aggregate's events at once, or out of order.
+type OutboxStore interface {
+	// ClaimOutbox leases up to limit aggregates whose oldest pending message is due
+	// and returns all their pending messages, ordered by ID
+	ClaimOutbox(ctx context.Context, owner string, lease time.Duration, limit int) ([]OutboxMessage, error)
+
+	// MarkPublished records that a leased message was published
+	MarkPublished(ctx context.Context, owner string, id int64) error
+
+	// MarkFailed records a failed attempt, schedules the retry and releases the
+	// owner's lease on the message's aggregate
+	MarkFailed(ctx context.Context, owner string, id int64, retryAt time.Time, lastError string) error
+
+	// ReleaseOutbox releases every lease held by owner
+	ReleaseOutbox(ctx context.Context, owner string) error
+
+	// PurgePublished deletes messages published before the given time
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/google/uuid"
+)
+
+// PayloadStore reads the whole history in commit order and replaces stored
+// payloads. It is only used by offline schema migrations.
+type PayloadStore interface {
+	ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error)
+	RewriteEventPayload(ctx context.Context, eventID uuid.UUID, eventVersion int, data []byte) error
+}
+
+// PayloadMigration upcasts stored event payloads to their latest schema
+type PayloadMigration struct {
+	Store      PayloadStore
+	Upcasters  *events.UpcasterRegistry
+	EventTypes *events.TypeRegistry
+
+	// Rewrite stores the upcast payloads; otherwise the migration only verifies them
+	Rewrite bool
+	// From skips events at or before this global position
+	From int64
+	// BatchSize is the number of events read at a time
+	BatchSize int
+}
+
+// PayloadMigrationFailure is an event that cannot be upcast to a valid payload
+type PayloadMigrationFailure struct {
+	Event events.Event
+	Err   error
+}
+
+// PayloadMigrationReport summarises a payload migration run
+type PayloadMigrationReport struct {
+	Scanned   int
+	Outdated  int
+	Rewritten int
+	Failures  []PayloadMigrationFailure
+
+	// LastPosition is the position of the last event fully handled. A run that
+	// stopped early can be continued from it.
+	LastPosition int64
+}
+
+// Run scans history after From and upcasts every event. Events that cannot be
+// upcast or fail validation are reported and skipped; a read or rewrite error
+// stops the run and is returned with the report so far.
+func (m PayloadMigration) Run(ctx context.Context) (PayloadMigrationReport, error) {
+
+	batchSize := m.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	report := PayloadMigrationReport{LastPosition: m.From}
+
+	for {
+		batch, err := m.Store.ReadAll(ctx, report.LastPosition, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("failed to read events after position %d: %w", report.LastPosition, err)
+		}
+
+		for _, event := range batch {
+
+			upcasted, err := m.Upcasters.Upcast(event)
+			if err == nil {
+				err = m.EventTypes.Validate(upcasted)
+			}
+
+			switch {
+			case err != nil:
+				report.Failures = append(report.Failures, PayloadMigrationFailure{Event: event, Err: err})
+
+			case upcasted.EventVersion != event.EventVersion:
+				if m.Rewrite {
+					if err := m.Store.RewriteEventPayload(ctx, event.EventID, upcasted.EventVersion, upcasted.Data); err != nil {
+						return report, fmt.Errorf("failed to rewrite event %s at position %d: %w", event.EventID, event.Position, err)
+					}
+					report.Rewritten++
+				}
+				report.Outdated++
+			}
+
+			report.Scanned++
+			report.LastPosition = event.Position
+		}
+
+		if len(batch) < batchSize {
+			return report, nil
+		}
+	}
+}
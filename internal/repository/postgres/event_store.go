@@ -4,14 +4,42 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the PostgreSQL error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// streamSequenceIndex is the unique index keeping sequence numbers distinct within a stream
+const streamSequenceIndex = "idx_events_stream_sequence"
+
+// positionLockKey is the advisory lock that serializes global position assignment
+const positionLockKey = 7_230_001
+
+// eventColumns is the column list shared by every event read.
+// JSON payloads live in data and binary formats in payload.
+const eventColumns = `
+	event_id, aggregate_type, aggregate_id, event_type,
+	event_version, sequence_number, global_position,
+	COALESCE(payload, convert_to(data::text, 'UTF8')), metadata, created_at,
+	COALESCE(correlation_id, ''), COALESCE(causation_id, ''),
+	COALESCE(user_id, ''), COALESCE(trace_id, '')
+`
+
 // PostgresEventStore implements the EventStore interface using PostgreSQL
 type PostgresEventStore struct {
-	db *sql.DB
+	db        *sql.DB
+	upcasters *events.UpcasterRegistry
+	types     *events.TypeRegistry
 }
 
 // NewPostgresEventStore creates a new PostgresEventStore
@@ -19,46 +47,183 @@ func NewPostgresEventStore(db *sql.DB) *PostgresEventStore {
 	return &PostgresEventStore{db: db}
 }
 
-// AppendEvents implements the EventStore interface
-func (s *PostgresEventStore) AppendEvents(ctx context.Context, events []events.Event) error {
+// WithUpcasters makes every read upcast events to their latest schema version
+func (s *PostgresEventStore) WithUpcasters(upcasters *events.UpcasterRegistry) *PostgresEventStore {
+	s.upcasters = upcasters
+	return s
+}
+
+// WithStrictTypes rejects appends whose payloads do not match the schema
+// registered for their event type
+func (s *PostgresEventStore) WithStrictTypes(types *events.TypeRegistry) *PostgresEventStore {
+	s.types = types
+	return s
+}
+
+// AppendEvents implements the EventStore interface. Events keep the sequence
+// numbers they were given; an event whose sequence is already taken in its
+// stream fails the append with a *ConcurrencyConflictError.
+func (s *PostgresEventStore) AppendEvents(ctx context.Context, batch []events.Event) error {
+	events.StampTrace(ctx, batch)
+
+	if err := s.validate(batch); err != nil {
+		return err
+	}
+
+	if replayed, err := findAppended(ctx, s.db, batch); replayed || err != nil {
+		return err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO events (
-			event_id, aggregate_type, aggregate_id, event_type,
-			event_version, sequence_number, data, metadata, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`)
-	if err != nil {
+	// Take the same stream locks as AppendToStream so the two never interleave
+	if err := lockStreams(ctx, tx, batch); err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	for _, event := range events {
-		_, err = stmt.ExecContext(
-			ctx,
-			event.EventID,
-			event.AggregateType,
-			event.AggregateID,
-			event.EventType,
-			event.EventVersion,
-			event.Sequence,
-			event.Data,
-			event.Metadata,
-			event.CreatedAt,
-		)
-		if err != nil {
-			return err
+	// A concurrent retry may have committed while we waited for the locks
+	if replayed, err := findAppended(ctx, tx, batch); replayed || err != nil {
+		return err
+	}
+
+	if err := checkSequences(ctx, tx, batch); err != nil {
+		return err
+	}
+
+	if err := insertEvents(ctx, tx, batch); err != nil {
+		if isDuplicateEventID(err) {
+			// A concurrent retry committed first
+			tx.Rollback()
+			return s.replayAppend(ctx, batch)
 		}
+		return err
+	}
+
+	if _, err := assignPositions(ctx, tx, batch); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
+// AppendToStream implements the EventStore interface
+func (s *PostgresEventStore) AppendToStream(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	expectedVersion int64,
+	newEvents []events.Event,
+) (int64, error) {
+	events.StampTrace(ctx, newEvents)
+
+	if err := s.validate(newEvents); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Serialize writers of the same stream for the rest of the transaction
+	if err := lockStream(ctx, tx, aggregateType, aggregateID); err != nil {
+		return 0, err
+	}
+
+	// A retry of an append that committed is answered before the version
+	// check, which the original append has since moved past
+	if replayed, err := findAppended(ctx, tx, newEvents); replayed || err != nil {
+		return lastPosition(newEvents), err
+	}
+
+	var currentVersion int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(sequence_number), 0)
+		FROM events
+		WHERE aggregate_type = $1 AND aggregate_id = $2
+	`, aggregateType, aggregateID).Scan(&currentVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	if !repository.VersionMatches(expectedVersion, currentVersion) {
+		return 0, &repository.ConcurrencyConflictError{
+			AggregateType:   aggregateType,
+			AggregateID:     aggregateID,
+			ExpectedVersion: expectedVersion,
+			ActualVersion:   currentVersion,
+		}
+	}
+
+	stream := make([]events.Event, len(newEvents))
+	for i, event := range newEvents {
+		event.AggregateType = aggregateType
+		event.AggregateID = aggregateID
+		event.Sequence = currentVersion + int64(i) + 1
+		stream[i] = event
+	}
+
+	if err := insertEvents(ctx, tx, stream); err != nil {
+		if isDuplicateEventID(err) {
+			tx.Rollback()
+			if err := s.replayAppend(ctx, newEvents); err != nil {
+				return 0, err
+			}
+			return lastPosition(newEvents), nil
+		}
+
+		// A writer that bypassed the lock got there first
+		if isSequenceTaken(err) {
+			return 0, &repository.ConcurrencyConflictError{
+				AggregateType:   aggregateType,
+				AggregateID:     aggregateID,
+				ExpectedVersion: expectedVersion,
+				ActualVersion:   currentVersion + 1,
+			}
+		}
+		return 0, err
+	}
+
+	position, err := assignPositions(ctx, tx, stream)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// Hand the stored stream fields back, e.g. for caches to append
+	copy(newEvents, stream)
+
+	return position, nil
+}
+
+// ReadAll implements the EventStore interface
+func (s *PostgresEventStore) ReadAll(
+	ctx context.Context,
+	fromPosition int64,
+	limit int,
+) ([]events.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE global_position > $1
+		ORDER BY global_position ASC
+		LIMIT $2
+	`, fromPosition, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.readEvents(rows)
+}
+
 // GetEventsByAggregateID implements the EventStore interface
 func (s *PostgresEventStore) GetEventsByAggregateID(
 	ctx context.Context,
@@ -66,8 +231,7 @@ func (s *PostgresEventStore) GetEventsByAggregateID(
 	aggregateID uuid.UUID,
 ) ([]events.Event, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT event_id, aggregate_type, aggregate_id, event_type,
-		       event_version, sequence_number, data, metadata, created_at
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE aggregate_type = $1 AND aggregate_id = $2
 		ORDER BY sequence_number ASC
@@ -75,35 +239,8 @@ func (s *PostgresEventStore) GetEventsByAggregateID(
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var result []events.Event
-	for rows.Next() {
-		var event events.Event
-		var metadataJSON []byte
-		err := rows.Scan(
-			&event.EventID,
-			&event.AggregateType,
-			&event.AggregateID,
-			&event.EventType,
-			&event.EventVersion,
-			&event.Sequence,
-			&event.Data,
-			&metadataJSON,
-			&event.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
-			return nil, err
-		}
 
-		result = append(result, event)
-	}
-
-	return result, rows.Err()
+	return s.readEvents(rows)
 }
 
 // GetEventsByType implements the EventStore interface
@@ -112,44 +249,114 @@ func (s *PostgresEventStore) GetEventsByType(
 	eventType events.EventType,
 ) ([]events.Event, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT event_id, aggregate_type, aggregate_id, event_type,
-		       event_version, sequence_number, data, metadata, created_at
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE event_type = $1
-		ORDER BY sequence_number ASC
+		ORDER BY global_position ASC
 	`, eventType)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var result []events.Event
-	for rows.Next() {
-		var event events.Event
-		var metadataJSON []byte
-		err := rows.Scan(
-			&event.EventID,
-			&event.AggregateType,
-			&event.AggregateID,
-			&event.EventType,
-			&event.EventVersion,
-			&event.Sequence,
-			&event.Data,
-			&metadataJSON,
-			&event.CreatedAt,
-		)
+	return s.readEvents(rows)
+}
+
+// GetEventsByCorrelationID implements the EventStore interface
+func (s *PostgresEventStore) GetEventsByCorrelationID(
+	ctx context.Context,
+	correlationID string,
+) ([]events.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+eventColumns+`
+		FROM events
+		WHERE correlation_id = $1
+		ORDER BY global_position ASC
+	`, correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.readEvents(rows)
+}
+
+// QueryEvents implements the EventStore interface
+func (s *PostgresEventStore) QueryEvents(
+	ctx context.Context,
+	query repository.EventQuery,
+) ([]events.Event, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	var where []string
+	var args []interface{}
+
+	addFilter := func(condition string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(query.AggregateTypes) > 0 {
+		addFilter("aggregate_type = ANY($%d)", pq.Array(query.AggregateTypes))
+	}
+	if len(query.AggregateIDs) > 0 {
+		ids := make([]string, len(query.AggregateIDs))
+		for i, id := range query.AggregateIDs {
+			ids[i] = id.String()
+		}
+		addFilter("aggregate_id = ANY($%d::uuid[])", pq.Array(ids))
+	}
+	if len(query.EventTypes) > 0 {
+		eventTypes := make([]string, len(query.EventTypes))
+		for i, eventType := range query.EventTypes {
+			eventTypes[i] = string(eventType)
+		}
+		addFilter("event_type = ANY($%d)", pq.Array(eventTypes))
+	}
+	if !query.From.IsZero() {
+		addFilter("created_at >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		addFilter("created_at < $%d", query.To)
+	}
+	if len(query.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(query.Metadata)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: metadata: %v", repository.ErrInvalidEventQuery, err)
 		}
+		addFilter("metadata @> $%d::jsonb", string(metadataJSON))
+	}
 
-		if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
-			return nil, err
+	direction := "ASC"
+	if query.Descending {
+		direction = "DESC"
+	}
+
+	if query.After > 0 {
+		if query.Descending {
+			addFilter("global_position < $%d", query.After)
+		} else {
+			addFilter("global_position > $%d", query.After)
 		}
+	}
 
-		result = append(result, event)
+	// Positions are assigned just before commit, so only committed events have one
+	where = append(where, "global_position IS NOT NULL")
+
+	sqlQuery := `SELECT ` + eventColumns + ` FROM events WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY global_position ` + direction
+
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		sqlQuery += fmt.Sprintf(` LIMIT $%d`, len(args))
 	}
 
-	return result, rows.Err()
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.readEvents(rows)
 }
 
 // GetEventsAfterSequence implements the EventStore interface
@@ -158,8 +365,7 @@ func (s *PostgresEventStore) GetEventsAfterSequence(
 	sequence int64,
 ) ([]events.Event, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT event_id, aggregate_type, aggregate_id, event_type,
-		       event_version, sequence_number, data, metadata, created_at
+		SELECT `+eventColumns+`
 		FROM events
 		WHERE sequence_number > $1
 		ORDER BY sequence_number ASC
@@ -167,28 +373,397 @@ func (s *PostgresEventStore) GetEventsAfterSequence(
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var result []events.Event
-	for rows.Next() {
-		var event events.Event
-		var metadataJSON []byte
-		err := rows.Scan(
-			&event.EventID,
-			&event.AggregateType,
-			&event.AggregateID,
-			&event.EventType,
-			&event.EventVersion,
-			&event.Sequence,
-			&event.Data,
-			&metadataJSON,
-			&event.CreatedAt,
-		)
+	return s.readEvents(rows)
+}
+
+// StreamEventsByAggregateID implements the EventStore interface
+func (s *PostgresEventStore) StreamEventsByAggregateID(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	opts repository.ReadOptions,
+	handler repository.EventHandler,
+) error {
+	lastSequence := opts.After
+
+	return streamPages(opts, handler, func(limit int) ([]events.Event, error) {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT `+eventColumns+`
+			FROM events
+			WHERE aggregate_type = $1 AND aggregate_id = $2 AND sequence_number > $3
+			ORDER BY sequence_number ASC
+			LIMIT $4
+		`, aggregateType, aggregateID, lastSequence, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := s.readEvents(rows)
+		if len(page) > 0 {
+			lastSequence = page[len(page)-1].Sequence
+		}
+		return page, err
+	})
+}
+
+// StreamEventsByType implements the EventStore interface
+func (s *PostgresEventStore) StreamEventsByType(
+	ctx context.Context,
+	eventType events.EventType,
+	opts repository.ReadOptions,
+	handler repository.EventHandler,
+) error {
+	lastPosition := opts.After
+
+	return streamPages(opts, handler, func(limit int) ([]events.Event, error) {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT `+eventColumns+`
+			FROM events
+			WHERE event_type = $1 AND global_position > $2
+			ORDER BY global_position ASC
+			LIMIT $3
+		`, eventType, lastPosition, limit)
 		if err != nil {
 			return nil, err
 		}
 
-		if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
+		page, err := s.readEvents(rows)
+		if len(page) > 0 {
+			lastPosition = page[len(page)-1].Position
+		}
+		return page, err
+	})
+}
+
+// StreamEventsAfterSequence implements the EventStore interface
+func (s *PostgresEventStore) StreamEventsAfterSequence(
+	ctx context.Context,
+	sequence int64,
+	opts repository.ReadOptions,
+	handler repository.EventHandler,
+) error {
+	// Sequence numbers repeat across aggregates, so the keyset also includes the position
+	lastSequence, lastPosition := sequence, int64(math.MaxInt64)
+
+	return streamPages(opts, handler, func(limit int) ([]events.Event, error) {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT `+eventColumns+`
+			FROM events
+			WHERE (sequence_number, global_position) > ($1, $2)
+			ORDER BY sequence_number ASC, global_position ASC
+			LIMIT $3
+		`, lastSequence, lastPosition, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := s.readEvents(rows)
+		if len(page) > 0 {
+			lastSequence = page[len(page)-1].Sequence
+			lastPosition = page[len(page)-1].Position
+		}
+		return page, err
+	})
+}
+
+// streamPages calls fetch until a short page or the limit is reached,
+// handing every event to handler. fetch is responsible for advancing its cursor.
+func streamPages(
+	opts repository.ReadOptions,
+	handler repository.EventHandler,
+	fetch func(limit int) ([]events.Event, error),
+) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = repository.DefaultBatchSize
+	}
+
+	delivered := 0
+	for {
+		limit := batchSize
+		if opts.Limit > 0 && opts.Limit-delivered < limit {
+			limit = opts.Limit - delivered
+		}
+		if limit <= 0 {
+			return nil
+		}
+
+		page, err := fetch(limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range page {
+			if err := handler(event); err != nil {
+				return err
+			}
+			delivered++
+		}
+
+		if len(page) < limit {
+			return nil
+		}
+	}
+}
+
+// insertEvents writes events without a global position, together with
+// their outbox entries so the publisher sees exactly the committed events
+func insertEvents(ctx context.Context, tx *sql.Tx, batch []events.Event) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO events (
+			event_id, aggregate_type, aggregate_id, event_type,
+			event_version, sequence_number, data, payload, metadata, created_at,
+			correlation_id, causation_id, user_id, trace_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, '')
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	outboxStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO outbox (event_id, aggregate_type, aggregate_id)
+		VALUES ($1, $2, $3)
+	`)
+	if err != nil {
+		return err
+	}
+	defer outboxStmt.Close()
+
+	for _, event := range batch {
+		metadataJSON, err := json.Marshal(event.Metadata)
+		if err != nil {
+			return err
+		}
+
+		var data, payload []byte
+		if events.FormatOf(event) == events.FormatJSON {
+			data = event.Data
+		} else {
+			payload = event.Data
+		}
+
+		_, err = stmt.ExecContext(
+			ctx,
+			event.EventID,
+			event.AggregateType,
+			event.AggregateID,
+			event.EventType,
+			event.EventVersion,
+			event.Sequence,
+			data,
+			payload,
+			metadataJSON,
+			event.CreatedAt,
+			event.CorrelationID,
+			event.CausationID,
+			event.UserID,
+			event.TraceID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = outboxStmt.ExecContext(ctx, event.EventID, event.AggregateType, event.AggregateID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignPositions gives the inserted events their global positions and returns the last one.
+// It must be the final step before commit: the lock is held until the transaction ends,
+// so positions become visible in commit order and readers never skip an in-flight event.
+func assignPositions(ctx context.Context, tx *sql.Tx, events []events.Event) (int64, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, positionLockKey); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE events
+		SET global_position = nextval('events_global_position_seq')
+		WHERE event_id = $1
+		RETURNING global_position
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var position int64
+	for i := range events {
+		if err := stmt.QueryRowContext(ctx, events[i].EventID).Scan(&position); err != nil {
+			return 0, err
+		}
+		events[i].Position = position
+	}
+
+	return position, nil
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// findAppended checks whether a batch was appended before. It reports true and
+// sets the original positions when every event ID is stored with the same
+// stream and sequence, false when none is, and ErrDuplicateEventID otherwise.
+// Appends to a stream leave the sequence unset, so it is only compared when given.
+func findAppended(ctx context.Context, q queryer, batch []events.Event) (bool, error) {
+	if len(batch) == 0 {
+		return false, nil
+	}
+
+	ids := make([]string, len(batch))
+	for i, event := range batch {
+		ids[i] = event.EventID.String()
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT event_id, aggregate_type, aggregate_id, sequence_number, global_position
+		FROM events
+		WHERE event_id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	type storedEvent struct {
+		aggregateType string
+		aggregateID   uuid.UUID
+		sequence      int64
+		position      sql.NullInt64
+	}
+
+	stored := make(map[uuid.UUID]storedEvent)
+	for rows.Next() {
+		var id uuid.UUID
+		var event storedEvent
+		if err := rows.Scan(&id, &event.aggregateType, &event.aggregateID, &event.sequence, &event.position); err != nil {
+			return false, err
+		}
+		stored[id] = event
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	if len(stored) == 0 {
+		return false, nil
+	}
+
+	// The whole batch must match before any position is handed back
+	positions := make([]int64, len(batch))
+	for i, event := range batch {
+		original, ok := stored[event.EventID]
+		sameStream := ok &&
+			(event.AggregateType == "" || event.AggregateType == original.aggregateType) &&
+			(event.AggregateID == uuid.Nil || event.AggregateID == original.aggregateID) &&
+			(event.Sequence == 0 || event.Sequence == original.sequence)
+
+		if !sameStream {
+			return false, fmt.Errorf("%w: %s", repository.ErrDuplicateEventID, event.EventID)
+		}
+
+		positions[i] = original.position.Int64
+	}
+
+	for i := range batch {
+		batch[i].Position = positions[i]
+	}
+
+	return true, nil
+}
+
+// replayAppend resolves an append that lost a primary key race to a retry of itself
+func (s *PostgresEventStore) replayAppend(ctx context.Context, batch []events.Event) error {
+	replayed, err := findAppended(ctx, s.db, batch)
+	if err != nil {
+		return err
+	}
+	if !replayed {
+		return fmt.Errorf("%w: event stored concurrently was not found", repository.ErrDuplicateEventID)
+	}
+	return nil
+}
+
+// isDuplicateEventID reports whether err is a primary key violation on events
+func isDuplicateEventID(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "events_pkey"
+}
+
+// lastPosition returns the position of the last event in a batch
+func lastPosition(batch []events.Event) int64 {
+	if len(batch) == 0 {
+		return 0
+	}
+	return batch[len(batch)-1].Position
+}
+
+// RewriteEventPayload replaces the stored payload and schema version of an event.
+// It is reserved for offline schema migrations; history is otherwise immutable.
+func (s *PostgresEventStore) RewriteEventPayload(
+	ctx context.Context,
+	eventID uuid.UUID,
+	eventVersion int,
+	data []byte,
+) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE events
+		SET event_version = $1, data = $2
+		WHERE event_id = $3
+	`, eventVersion, data, eventID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("event %s not found", eventID)
+	}
+
+	return nil
+}
+
+// validate checks events against the registered schemas in strict mode
+func (s *PostgresEventStore) validate(events []events.Event) error {
+	if s.types == nil {
+		return nil
+	}
+
+	return s.types.ValidateAll(events)
+}
+
+// readEvents scans rows and upcasts the events to their latest schema
+func (s *PostgresEventStore) readEvents(rows *sql.Rows) ([]events.Event, error) {
+	result, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.upcasters.UpcastAll(result)
+}
+
+// scanEvents reads every row into events and closes rows
+func scanEvents(rows *sql.Rows) ([]events.Event, error) {
+	defer rows.Close()
+
+	var result []events.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -197,3 +772,120 @@ func (s *PostgresEventStore) GetEventsAfterSequence(
 
 	return result, rows.Err()
 }
+
+// lockStream takes the advisory lock serializing writers of one stream until the transaction ends
+func lockStream(ctx context.Context, tx *sql.Tx, aggregateType string, aggregateID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2::text))
+	`, aggregateType, aggregateID)
+	return err
+}
+
+// lockStreams locks every stream written by a batch, in a fixed order so
+// concurrent batches touching the same streams cannot deadlock
+func lockStreams(ctx context.Context, tx *sql.Tx, batch []events.Event) error {
+	type stream struct {
+		aggregateType string
+		aggregateID   uuid.UUID
+	}
+
+	seen := make(map[stream]bool)
+	var streams []stream
+	for _, event := range batch {
+		key := stream{aggregateType: event.AggregateType, aggregateID: event.AggregateID}
+		if !seen[key] {
+			seen[key] = true
+			streams = append(streams, key)
+		}
+	}
+
+	sort.Slice(streams, func(i, j int) bool {
+		if streams[i].aggregateType != streams[j].aggregateType {
+			return streams[i].aggregateType < streams[j].aggregateType
+		}
+		return streams[i].aggregateID.String() < streams[j].aggregateID.String()
+	})
+
+	for _, key := range streams {
+		if err := lockStream(ctx, tx, key.aggregateType, key.aggregateID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSequences fails with a *ConcurrencyConflictError when a batch reuses a
+// sequence number already stored in its stream. The streams must be locked.
+func checkSequences(ctx context.Context, tx *sql.Tx, batch []events.Event) error {
+	current := make(map[string]int64)
+
+	for _, event := range batch {
+		key := event.AggregateType + ":" + event.AggregateID.String()
+
+		version, ok := current[key]
+		if !ok {
+			err := tx.QueryRowContext(ctx, `
+				SELECT COALESCE(MAX(sequence_number), 0)
+				FROM events
+				WHERE aggregate_type = $1 AND aggregate_id = $2
+			`, event.AggregateType, event.AggregateID).Scan(&version)
+			if err != nil {
+				return err
+			}
+			current[key] = version
+		}
+
+		if event.Sequence <= version {
+			return &repository.ConcurrencyConflictError{
+				AggregateType:   event.AggregateType,
+				AggregateID:     event.AggregateID,
+				ExpectedVersion: event.Sequence - 1,
+				ActualVersion:   version,
+			}
+		}
+	}
+
+	return nil
+}
+
+// isSequenceTaken reports whether err is a violation of the unique sequence numbers of a stream
+func isSequenceTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == streamSequenceIndex
+}
+
+// scanEvent scans a row of eventColumns, preceded by any extra columns in prefix
+func scanEvent(row rowScanner, prefix ...interface{}) (events.Event, error) {
+	var event events.Event
+	var position sql.NullInt64
+	var metadataJSON []byte
+
+	dest := append(prefix,
+		&event.EventID,
+		&event.AggregateType,
+		&event.AggregateID,
+		&event.EventType,
+		&event.EventVersion,
+		&event.Sequence,
+		&position,
+		&event.Data,
+		&metadataJSON,
+		&event.CreatedAt,
+		&event.CorrelationID,
+		&event.CausationID,
+		&event.UserID,
+		&event.TraceID,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return event, err
+	}
+
+	event.Position = position.Int64
+
+	if err := json.Unmarshal(metadataJSON, &event.Metadata); err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/google/uuid"
+)
+
+// staleReservation is how long an uncompleted key blocks retries. Past it the
+// original request is assumed lost, e.g. to a crashed pod, and the key is taken over.
+const staleReservation = time.Minute
+
+// PostgresIdempotencyStore implements the IdempotencyStore interface using PostgreSQL
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewPostgresIdempotencyStore creates a new PostgresIdempotencyStore
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Reserve implements the IdempotencyStore interface.
+// An expired or stale key is taken over as if it did not exist.
+func (s *PostgresIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	requestHash string,
+	window time.Duration,
+) (repository.IdempotencyRecord, bool, error) {
+	now := time.Now()
+	token := uuid.New()
+
+	var reserved string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (
+			idempotency_key, request_hash, reservation_token, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (idempotency_key)
+		DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			reservation_token = EXCLUDED.reservation_token,
+			status_code = NULL,
+			content_type = NULL,
+			response = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < $4
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $6)
+		RETURNING idempotency_key
+	`, key, requestHash, token, now, now.Add(window), now.Add(-staleReservation)).Scan(&reserved)
+
+	if err == nil {
+		return repository.IdempotencyRecord{
+			Key:         key,
+			RequestHash: requestHash,
+			Token:       token.String(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(window),
+		}, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return repository.IdempotencyRecord{}, false, err
+	}
+
+	record, err := s.get(ctx, key)
+	return record, false, err
+}
+
+// Complete implements the IdempotencyStore interface.
+// A response is only stored while the reservation still holds the key, so a
+// request that outlived its stale reservation cannot overwrite the takeover.
+func (s *PostgresIdempotencyStore) Complete(
+	ctx context.Context,
+	key string,
+	token string,
+	statusCode int,
+	contentType string,
+	response []byte,
+) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response = $3
+		WHERE idempotency_key = $4 AND reservation_token = $5 AND status_code IS NULL
+	`, statusCode, contentType, response, key, token)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repository.ErrReservationLost
+	}
+
+	return nil
+}
+
+// Release implements the IdempotencyStore interface.
+// A key taken over by another reservation is left alone.
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key, token string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE idempotency_key = $1 AND reservation_token = $2 AND status_code IS NULL
+	`, key, token)
+
+	return err
+}
+
+// PurgeExpired implements the IdempotencyStore interface
+func (s *PostgresIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE expires_at < $1
+	`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *PostgresIdempotencyStore) get(ctx context.Context, key string) (repository.IdempotencyRecord, error) {
+	record := repository.IdempotencyRecord{Key: key}
+
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, content_type, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE idempotency_key = $1
+	`, key).Scan(
+		&record.RequestHash,
+		&statusCode,
+		&contentType,
+		&record.Response,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return record, err
+	}
+
+	record.Completed = statusCode.Valid
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+
+	return record, nil
+}
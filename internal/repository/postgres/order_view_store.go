@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/google/uuid"
+)
+
+// defaultPageSize and maxPageSize bound order listings
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// orderSortColumns maps sort fields to order_views columns
+var orderSortColumns = map[repository.OrderSortField]string{
+	repository.SortByCreatedAt:   "created_at",
+	repository.SortByUpdatedAt:   "updated_at",
+	repository.SortByTotalAmount: "total_amount",
+}
+
+const orderViewColumns = `
+	order_id, customer_id, status, items, item_count,
+	total_amount, version, created_at, updated_at
+`
+
+// orderCursor is the decoded form of a listing cursor: the sort key of the last row
+type orderCursor struct {
+	SortBy repository.OrderSortField `json:"s"`
+	Desc   bool                      `json:"d"`
+	Time   time.Time                 `json:"t,omitempty"`
+	Amount float64                   `json:"a,omitempty"`
+	ID     uuid.UUID                 `json:"id"`
+}
+
+// PostgresOrderViewStore implements the OrderViewStore interface using PostgreSQL
+type PostgresOrderViewStore struct {
+	db *sql.DB
+}
+
+// NewPostgresOrderViewStore creates a new PostgresOrderViewStore
+func NewPostgresOrderViewStore(db *sql.DB) *PostgresOrderViewStore {
+	return &PostgresOrderViewStore{db: db}
+}
+
+// GetOrder implements the OrderViewStore interface
+func (s *PostgresOrderViewStore) GetOrder(ctx context.Context, id uuid.UUID) (repository.OrderView, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+orderViewColumns+`
+		FROM order_views
+		WHERE order_id = $1
+	`, id)
+
+	view, err := scanOrderView(row)
+	if err == sql.ErrNoRows {
+		return view, repository.ErrOrderViewNotFound
+	}
+
+	return view, err
+}
+
+// ListOrders implements the OrderViewStore interface
+func (s *PostgresOrderViewStore) ListOrders(ctx context.Context, query repository.OrderQuery) (repository.OrderPage, error) {
+	var page repository.OrderPage
+
+	if query.SortBy == "" {
+		query.SortBy = repository.SortByCreatedAt
+	}
+
+	column, ok := orderSortColumns[query.SortBy]
+	if !ok {
+		return page, fmt.Errorf("unsupported sort field %q", query.SortBy)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	var where []string
+	var args []interface{}
+
+	addFilter := func(condition string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.CustomerID != uuid.Nil {
+		addFilter("customer_id = $%d", query.CustomerID)
+	}
+	if query.Status != "" {
+		addFilter("status = $%d", query.Status)
+	}
+	if !query.From.IsZero() {
+		addFilter("created_at >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		addFilter("created_at < $%d", query.To)
+	}
+	if query.MinTotal != nil {
+		addFilter("total_amount >= $%d", *query.MinTotal)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeOrderCursor(query.Cursor)
+		if err != nil || cursor.SortBy != query.SortBy || cursor.Desc != query.Descending {
+			return page, repository.ErrInvalidCursor
+		}
+
+		var value interface{} = cursor.Time
+		if query.SortBy == repository.SortByTotalAmount {
+			value = cursor.Amount
+		}
+
+		args = append(args, value, cursor.ID)
+		where = append(where, fmt.Sprintf("(%s, order_id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
+	}
+
+	sqlQuery := `SELECT ` + orderViewColumns + ` FROM order_views`
+	if len(where) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	args = append(args, limit+1)
+	sqlQuery += fmt.Sprintf(` ORDER BY %s %s, order_id %s LIMIT $%d`, column, direction, direction, len(args))
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	page.Orders = make([]repository.OrderView, 0, limit)
+	for rows.Next() {
+		view, err := scanOrderView(rows)
+		if err != nil {
+			return page, err
+		}
+		page.Orders = append(page.Orders, view)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	if len(page.Orders) > limit {
+		page.Orders = page.Orders[:limit]
+		page.NextCursor = encodeOrderCursor(query, page.Orders[limit-1])
+	}
+
+	return page, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrderView(row rowScanner) (repository.OrderView, error) {
+	var view repository.OrderView
+	var itemsJSON []byte
+
+	err := row.Scan(
+		&view.ID,
+		&view.CustomerID,
+		&view.Status,
+		&itemsJSON,
+		&view.ItemCount,
+		&view.TotalAmount,
+		&view.Version,
+		&view.CreatedAt,
+		&view.UpdatedAt,
+	)
+	if err != nil {
+		return view, err
+	}
+
+	if err := json.Unmarshal(itemsJSON, &view.Items); err != nil {
+		return view, err
+	}
+
+	return view, nil
+}
+
+func encodeOrderCursor(query repository.OrderQuery, last repository.OrderView) string {
+	cursor := orderCursor{
+		SortBy: query.SortBy,
+		Desc:   query.Descending,
+		ID:     last.ID,
+	}
+
+	switch query.SortBy {
+	case repository.SortByUpdatedAt:
+		cursor.Time = last.UpdatedAt
+	case repository.SortByTotalAmount:
+		cursor.Amount = last.TotalAmount
+	default:
+		cursor.Time = last.CreatedAt
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(encoded string) (orderCursor, error) {
+	var cursor orderCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
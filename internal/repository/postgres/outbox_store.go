@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+)
+
+// PostgresOutboxStore implements the OutboxStore interface using PostgreSQL
+type PostgresOutboxStore struct {
+	db *sql.DB
+}
+
+// NewPostgresOutboxStore creates a new PostgresOutboxStore
+func NewPostgresOutboxStore(db *sql.DB) *PostgresOutboxStore {
+	return &PostgresOutboxStore{db: db}
+}
+
+// ClaimOutbox implements the OutboxStore interface.
+// An aggregate is claimable when its oldest pending message is due and not
+// leased; the lease then covers every pending message of the aggregate.
+func (s *PostgresOutboxStore) ClaimOutbox(
+	ctx context.Context,
+	owner string,
+	lease time.Duration,
+	limit int,
+) ([]repository.OutboxMessage, error) {
+	now := time.Now()
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH heads AS (
+			SELECT h.aggregate_type, h.aggregate_id
+			FROM outbox h
+			WHERE h.published_at IS NULL
+				AND h.next_attempt_at <= $1
+				AND (h.leased_until IS NULL OR h.leased_until < $1)
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox e
+					WHERE e.published_at IS NULL
+						AND e.aggregate_type = h.aggregate_type
+						AND e.aggregate_id = h.aggregate_id
+						AND e.id < h.id
+				)
+			ORDER BY h.id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE outbox o
+			SET lease_owner = $3, leased_until = $4
+			FROM heads
+			WHERE o.published_at IS NULL
+				AND o.aggregate_type = heads.aggregate_type
+				AND o.aggregate_id = heads.aggregate_id
+			RETURNING o.id, o.attempts, o.event_id
+		)
+		SELECT claimed.id, claimed.attempts, `+eventColumns+`
+		FROM claimed
+		JOIN events USING (event_id)
+		ORDER BY claimed.id
+	`, now, limit, owner, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []repository.OutboxMessage
+	for rows.Next() {
+		var message repository.OutboxMessage
+
+		message.Event, err = scanEvent(rows, &message.ID, &message.Attempts)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, message)
+	}
+
+	return result, rows.Err()
+}
+
+// MarkPublished implements the OutboxStore interface
+func (s *PostgresOutboxStore) MarkPublished(ctx context.Context, owner string, id int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET published_at = $1, lease_owner = NULL, leased_until = NULL, last_error = ''
+		WHERE id = $2 AND lease_owner = $3
+	`, time.Now(), id, owner)
+	if err != nil {
+		return err
+	}
+
+	return requireLease(result)
+}
+
+// MarkFailed implements the OutboxStore interface
+func (s *PostgresOutboxStore) MarkFailed(
+	ctx context.Context,
+	owner string,
+	id int64,
+	retryAt time.Time,
+	lastError string,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var aggregateType, aggregateID string
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE outbox
+		SET attempts = attempts + 1, next_attempt_at = $1, last_error = $2
+		WHERE id = $3 AND lease_owner = $4
+		RETURNING aggregate_type, aggregate_id
+	`, retryAt, lastError, id, owner).Scan(&aggregateType, &aggregateID)
+
+	if err == sql.ErrNoRows {
+		return repository.ErrLeaseLost
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE outbox
+		SET lease_owner = NULL, leased_until = NULL
+		WHERE aggregate_type = $1 AND aggregate_id = $2 AND lease_owner = $3
+	`, aggregateType, aggregateID, owner)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseOutbox implements the OutboxStore interface
+func (s *PostgresOutboxStore) ReleaseOutbox(ctx context.Context, owner string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET lease_owner = NULL, leased_until = NULL
+		WHERE lease_owner = $1
+	`, owner)
+
+	return err
+}
+
+// PurgePublished implements the OutboxStore interface
+func (s *PostgresOutboxStore) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM outbox
+		WHERE published_at IS NOT NULL AND published_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// requireLease reports ErrLeaseLost when an update matched no leased row
+func requireLease(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return repository.ErrLeaseLost
+	}
+
+	return nil
+}
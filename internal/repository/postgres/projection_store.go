@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/HarshavardhanK/espm/internal/events"
 	"github.com/HarshavardhanK/espm/internal/repository"
 )
 
@@ -97,3 +98,184 @@ func (s *PostgresProjectionStore) UpdateProjectionState(
 
 	return nil
 }
+
+// GetCheckpoint implements the ProjectionStore interface
+func (s *PostgresProjectionStore) GetCheckpoint(
+	ctx context.Context,
+	projectionName string,
+) (int64, error) {
+	if err := s.ensureProjection(ctx, projectionName); err != nil {
+		return 0, err
+	}
+
+	var checkpoint int64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT checkpoint
+		FROM projections
+		WHERE projection_name = $1
+	`, projectionName).Scan(&checkpoint)
+
+	return checkpoint, err
+}
+
+// UpdateProjectionStatus implements the ProjectionStore interface
+func (s *PostgresProjectionStore) UpdateProjectionStatus(
+	ctx context.Context,
+	projectionName string,
+	status repository.ProjectionStatus,
+	lastError string,
+) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO projections (
+			projection_name, state, status, last_error, updated_at
+		) VALUES ($1, '{}', $2, $3, $4)
+		ON CONFLICT (projection_name)
+		DO UPDATE SET status = $2, last_error = $3, updated_at = $4
+	`, projectionName, status, lastError, time.Now())
+
+	return err
+}
+
+// ListProjections implements the ProjectionStore interface
+func (s *PostgresProjectionStore) ListProjections(ctx context.Context) ([]repository.ProjectionInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT projection_name, checkpoint, status, last_error, updated_at
+		FROM projections
+		ORDER BY projection_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []repository.ProjectionInfo
+	for rows.Next() {
+		var info repository.ProjectionInfo
+		if err := rows.Scan(&info.Name, &info.Checkpoint, &info.Status, &info.LastError, &info.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, info)
+	}
+
+	return result, rows.Err()
+}
+
+// ApplyBatch applies events to a read model and advances the projection's
+// checkpoint in the same transaction, so every event takes effect exactly once.
+// It fails with ErrCheckpointMoved if the checkpoint is no longer fromCheckpoint.
+func (s *PostgresProjectionStore) ApplyBatch(
+	ctx context.Context,
+	projectionName string,
+	fromCheckpoint int64,
+	batch []events.Event,
+	apply repository.ApplyFunc,
+) (int64, error) {
+	if len(batch) == 0 {
+		return fromCheckpoint, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCheckpoint(ctx, tx, projectionName, fromCheckpoint); err != nil {
+		return 0, err
+	}
+
+	for _, event := range batch {
+		if err := apply(ctx, tx, event); err != nil {
+			return 0, err
+		}
+	}
+
+	checkpoint := batch[len(batch)-1].Position
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE projections
+		SET checkpoint = $1, updated_at = $2
+		WHERE projection_name = $3
+	`, checkpoint, time.Now(), projectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	return checkpoint, tx.Commit()
+}
+
+// ResetProjection clears a read model with reset and rewinds the checkpoint
+// to zero in one transaction, marking the projection as rebuilding
+func (s *PostgresProjectionStore) ResetProjection(
+	ctx context.Context,
+	projectionName string,
+	reset repository.ResetFunc,
+) error {
+	if err := s.ensureProjection(ctx, projectionName); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		SELECT checkpoint FROM projections WHERE projection_name = $1 FOR UPDATE
+	`, projectionName)
+	if err != nil {
+		return err
+	}
+
+	if reset != nil {
+		if err := reset(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE projections
+		SET checkpoint = 0, status = $1, last_error = '', updated_at = $2
+		WHERE projection_name = $3
+	`, repository.ProjectionRebuilding, time.Now(), projectionName)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ensureProjection creates the projection row if it does not exist yet
+func (s *PostgresProjectionStore) ensureProjection(ctx context.Context, projectionName string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO projections (projection_name, state, updated_at)
+		VALUES ($1, '{}', $2)
+		ON CONFLICT (projection_name) DO NOTHING
+	`, projectionName, time.Now())
+
+	return err
+}
+
+// lockCheckpoint locks the projection row and verifies it is still at checkpoint
+func lockCheckpoint(ctx context.Context, tx *sql.Tx, projectionName string, checkpoint int64) error {
+	var current int64
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT checkpoint FROM projections WHERE projection_name = $1 FOR UPDATE
+	`, projectionName).Scan(&current)
+
+	if err == sql.ErrNoRows {
+		return repository.ErrProjectionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if current != checkpoint {
+		return repository.ErrCheckpointMoved
+	}
+
+	return nil
+}
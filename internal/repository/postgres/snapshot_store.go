@@ -4,15 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/pkg/compress"
 	"github.com/google/uuid"
 )
 
 // PostgresSnapshotStore implements the SnapshotStore interface using PostgreSQL
 type PostgresSnapshotStore struct {
-	db *sql.DB
+	db            *sql.DB
+	codec         compress.Codec
+	compressAbove int
 }
 
 // NewPostgresSnapshotStore creates a new PostgresSnapshotStore
@@ -20,12 +24,23 @@ func NewPostgresSnapshotStore(db *sql.DB) *PostgresSnapshotStore {
 	return &PostgresSnapshotStore{db: db}
 }
 
-// SaveSnapshot implements the SnapshotStore interface
+// WithCompression compresses snapshots of at least minSize bytes with codec.
+// Smaller snapshots stay in the JSONB data column. Snapshots are always read
+// with the algorithm recorded next to them, so changing it is safe.
+func (s *PostgresSnapshotStore) WithCompression(codec compress.Codec, minSize int) *PostgresSnapshotStore {
+	s.codec = codec
+	s.compressAbove = minSize
+	return s
+}
+
+// SaveSnapshot implements the SnapshotStore interface. A snapshot at a version
+// that is already stored replaces it, e.g. when regenerated in a new schema.
 func (s *PostgresSnapshotStore) SaveSnapshot(
 	ctx context.Context,
 	aggregateType string,
 	aggregateID uuid.UUID,
 	version int64,
+	schemaVersion int,
 	data interface{},
 ) error {
 	jsonData, err := json.Marshal(data)
@@ -33,13 +48,24 @@ func (s *PostgresSnapshotStore) SaveSnapshot(
 		return err
 	}
 
+	var compressed []byte
+	algorithm := compress.None
+
+	if s.codec != nil && s.codec.Algorithm() != compress.None && len(jsonData) >= s.compressAbove {
+		if compressed, err = s.codec.Compress(jsonData); err != nil {
+			return fmt.Errorf("failed to compress snapshot: %w", err)
+		}
+		jsonData = nil
+		algorithm = s.codec.Algorithm()
+	}
+
 	_, err = s.db.ExecContext(ctx, `
 		INSERT INTO snapshots (
-			aggregate_type, aggregate_id, version, data, created_at
-		) VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (aggregate_type, aggregate_id) 
-		DO UPDATE SET version = $3, data = $4, created_at = $5
-	`, aggregateType, aggregateID, version, jsonData, time.Now())
+			aggregate_type, aggregate_id, version, schema_version, data, compressed_data, compression, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (aggregate_type, aggregate_id, version)
+		DO UPDATE SET schema_version = $4, data = $5, compressed_data = $6, compression = $7, created_at = $8
+	`, aggregateType, aggregateID, version, schemaVersion, jsonData, compressed, string(algorithm), time.Now())
 
 	return err
 }
@@ -49,27 +75,136 @@ func (s *PostgresSnapshotStore) GetSnapshot(
 	ctx context.Context,
 	aggregateType string,
 	aggregateID uuid.UUID,
-	data interface{},
-) (int64, error) {
-	var version int64
-	var jsonData []byte
+	before int64,
+) (repository.Snapshot, error) {
+	var snapshot repository.Snapshot
+	var compressed []byte
+	var algorithm string
+
+	query := `
+		SELECT version, schema_version, created_at, data, compressed_data, compression
+		FROM snapshots
+		WHERE aggregate_type = $1 AND aggregate_id = $2
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	args := []interface{}{aggregateType, aggregateID}
+
+	if before > 0 {
+		query = `
+		SELECT version, schema_version, created_at, data, compressed_data, compression
+		FROM snapshots
+		WHERE aggregate_type = $1 AND aggregate_id = $2 AND version < $3
+		ORDER BY version DESC
+		LIMIT 1
+	`
+		args = append(args, before)
+	}
+
+	err := s.db.QueryRowContext(ctx, query, args...).
+		Scan(&snapshot.Version, &snapshot.SchemaVersion, &snapshot.CreatedAt, &snapshot.Data, &compressed, &algorithm)
+
+	if err == sql.ErrNoRows {
+		return snapshot, repository.ErrSnapshotNotFound
+	}
+	if err != nil {
+		return snapshot, err
+	}
+
+	if compress.Algorithm(algorithm) == compress.None {
+		return snapshot, nil
+	}
+
+	codec, err := compress.CodecFor(compress.Algorithm(algorithm))
+	if err != nil {
+		return snapshot, fmt.Errorf("%w: version %d: %v", repository.ErrSnapshotUnreadable, snapshot.Version, err)
+	}
+
+	if snapshot.Data, err = codec.Decompress(compressed); err != nil {
+		return snapshot, fmt.Errorf("%w: version %d: %v", repository.ErrSnapshotUnreadable, snapshot.Version, err)
+	}
+
+	return snapshot, nil
+}
+
+// GetSnapshotInfo implements the SnapshotStore interface
+func (s *PostgresSnapshotStore) GetSnapshotInfo(
+	ctx context.Context,
+	aggregateType string,
+	aggregateID uuid.UUID,
+) (repository.SnapshotInfo, error) {
+	var info repository.SnapshotInfo
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT version, data
+		SELECT version, schema_version, created_at
 		FROM snapshots
 		WHERE aggregate_type = $1 AND aggregate_id = $2
-	`, aggregateType, aggregateID).Scan(&version, &jsonData)
+		ORDER BY version DESC
+		LIMIT 1
+	`, aggregateType, aggregateID).Scan(&info.Version, &info.SchemaVersion, &info.CreatedAt)
 
 	if err == sql.ErrNoRows {
-		return 0, repository.ErrSnapshotNotFound
+		return info, repository.ErrSnapshotNotFound
 	}
+
+	return info, err
+}
+
+// ListOutdatedSnapshots implements the SnapshotStore interface
+func (s *PostgresSnapshotStore) ListOutdatedSnapshots(
+	ctx context.Context,
+	aggregateType string,
+	schemaVersion int,
+	after uuid.UUID,
+	limit int,
+) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT aggregate_id
+		FROM (
+			SELECT DISTINCT ON (aggregate_id) aggregate_id, schema_version
+			FROM snapshots
+			WHERE aggregate_type = $1 AND aggregate_id > $3
+			ORDER BY aggregate_id, version DESC
+		) latest
+		WHERE schema_version <> $2
+		ORDER BY aggregate_id
+		LIMIT $4
+	`, aggregateType, schemaVersion, after, limit)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	if err := json.Unmarshal(jsonData, data); err != nil {
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// PruneSnapshots implements the SnapshotStore interface
+func (s *PostgresSnapshotStore) PruneSnapshots(ctx context.Context, aggregateType string, keep int) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM snapshots s
+		USING (
+			SELECT aggregate_id, version,
+				row_number() OVER (PARTITION BY aggregate_id ORDER BY version DESC) AS rank
+			FROM snapshots
+			WHERE aggregate_type = $1
+		) ranked
+		WHERE s.aggregate_type = $1
+			AND s.aggregate_id = ranked.aggregate_id
+			AND s.version = ranked.version
+			AND ranked.rank > $2
+	`, aggregateType, keep)
+	if err != nil {
 		return 0, err
 	}
 
-	return version, nil
+	return result.RowsAffected()
 }
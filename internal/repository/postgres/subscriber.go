@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// EventsChannel is the NOTIFY channel raised when events receive a global position
+const EventsChannel = "events_appended"
+
+// PostgresNotifier implements the CommitNotifier interface using LISTEN/NOTIFY
+type PostgresNotifier struct {
+	connStr              string
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+}
+
+// NewPostgresNotifier creates a new PostgresNotifier. connStr is used for a
+// dedicated listener connection per Notify call, which reconnects with a
+// backoff between the given intervals.
+func NewPostgresNotifier(connStr string, minReconnectInterval, maxReconnectInterval time.Duration) *PostgresNotifier {
+	if minReconnectInterval <= 0 {
+		minReconnectInterval = time.Second
+	}
+	if maxReconnectInterval < minReconnectInterval {
+		maxReconnectInterval = time.Minute
+	}
+
+	return &PostgresNotifier{
+		connStr:              connStr,
+		minReconnectInterval: minReconnectInterval,
+		maxReconnectInterval: maxReconnectInterval,
+	}
+}
+
+// Notify implements the CommitNotifier interface
+func (n *PostgresNotifier) Notify(ctx context.Context) (<-chan struct{}, func() bool, error) {
+	var connected, listening atomic.Bool
+
+	signals := make(chan struct{}, 1)
+	signal := func() {
+		select {
+		case signals <- struct{}{}:
+		default:
+		}
+	}
+
+	listener := pq.NewListener(
+		n.connStr,
+		n.minReconnectInterval,
+		n.maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+				connected.Store(true)
+			case pq.ListenerEventDisconnected:
+				connected.Store(false)
+				log.Printf("event notifications disconnected, subscriptions fall back to polling: %v", err)
+			case pq.ListenerEventConnectionAttemptFailed:
+				log.Printf("event notifications reconnect failed: %v", err)
+			}
+		},
+	)
+
+	// Listen blocks until the first connection succeeds, so it runs apart from
+	// the caller, which polls meanwhile. Closing the listener aborts it.
+	go func() {
+		if err := listener.Listen(EventsChannel); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("failed to listen for event notifications: %v", err)
+			}
+			return
+		}
+
+		listening.Store(true)
+
+		// Commits between the caller's last read and LISTEN taking effect
+		signal()
+	}()
+
+	go func() {
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			// A nil notification follows a reconnect; either way there may be new events
+			case _, ok := <-listener.Notify:
+				if !ok {
+					return
+				}
+				signal()
+			}
+		}
+	}()
+
+	live := func() bool {
+		return listening.Load() && connected.Load()
+	}
+
+	return signals, live, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+)
+
+// flight is a stream load in progress
+type flight struct {
+	done   chan struct{}
+	stream []events.Event
+	err    error
+}
+
+// flightGroup coalesces concurrent loads of the same key, so only the first
+// caller does the work and the others wait for its result
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// do runs load unless a load of key is already in flight, in which case it
+// waits for that one. shared reports whether the result came from another
+// caller; shared streams are copies, so callers may modify them.
+func (g *flightGroup) do(key string, load func() ([]events.Event, error)) (stream []events.Event, shared bool, err error) {
+
+	g.mu.Lock()
+
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+
+		return append([]events.Event(nil), f.stream...), true, f.err
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.stream, f.err = load()
+
+	return f.stream, false, f.err
+}
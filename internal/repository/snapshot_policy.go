@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/google/uuid"
+)
+
+// SnapshotContext describes an aggregate right after a successful save
+type SnapshotContext struct {
+	AggregateType string
+	AggregateID   uuid.UUID
+
+	// Version is the aggregate version after the save
+	Version int64
+	// NewEvents are the events written by the save
+	NewEvents []events.Event
+
+	// Snapshot describes the latest stored snapshot, zero if there is none
+	Snapshot SnapshotInfo
+
+	// StateSize is the size in bytes of the encoded aggregate state
+	StateSize int
+
+	// Replay describes the load that preceded the save, zero if unknown
+	Replay ReplayStats
+}
+
+// ReplayStats describes how much work loading an aggregate took
+type ReplayStats struct {
+	// Events is the number of events replayed on top of the snapshot
+	Events int
+	// Duration is the time spent reading and applying them
+	Duration time.Duration
+}
+
+// EventsSinceSnapshot returns how many events a load would replay on top of the latest snapshot
+func (c SnapshotContext) EventsSinceSnapshot() int64 {
+	return c.Version - c.Snapshot.Version
+}
+
+// SnapshotPolicy decides whether an aggregate should be snapshotted after a save
+type SnapshotPolicy interface {
+	ShouldSnapshot(ctx SnapshotContext) bool
+}
+
+// SnapshotPolicyFunc adapts a function to the SnapshotPolicy interface
+type SnapshotPolicyFunc func(ctx SnapshotContext) bool
+
+// ShouldSnapshot implements SnapshotPolicy
+func (f SnapshotPolicyFunc) ShouldSnapshot(ctx SnapshotContext) bool {
+	return f(ctx)
+}
+
+// NeverSnapshot disables snapshotting
+func NeverSnapshot() SnapshotPolicy {
+	return SnapshotPolicyFunc(func(SnapshotContext) bool {
+		return false
+	})
+}
+
+// EveryNEvents snapshots once n events have been written since the latest snapshot
+func EveryNEvents(n int64) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		return n > 0 && ctx.EventsSinceSnapshot() >= n
+	})
+}
+
+// EveryInterval snapshots when the latest snapshot is older than interval
+// and the aggregate has changed since it was taken
+func EveryInterval(interval time.Duration) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		if ctx.EventsSinceSnapshot() <= 0 {
+			return false
+		}
+		return ctx.Snapshot.CreatedAt.IsZero() || time.Since(ctx.Snapshot.CreatedAt) >= interval
+	})
+}
+
+// StateSizeAbove snapshots when the encoded aggregate state reaches size bytes
+// and the aggregate has changed since the latest snapshot
+func StateSizeAbove(size int) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		return ctx.EventsSinceSnapshot() > 0 && ctx.StateSize >= size
+	})
+}
+
+// ReplayLongerThan snapshots when the load before the save spent at least d
+// replaying events
+func ReplayLongerThan(d time.Duration) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		return d > 0 && ctx.EventsSinceSnapshot() > 0 && ctx.Replay.Duration >= d
+	})
+}
+
+// OnEventTypes snapshots after a save that wrote an event of one of the given
+// types, typically ones after which the aggregate is mostly read
+func OnEventTypes(eventTypes ...events.EventType) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		for _, event := range ctx.NewEvents {
+			for _, eventType := range eventTypes {
+				if event.EventType == eventType {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// PolicyFromConfig builds the policy described by cfg, which snapshots
+// when any of its rules matches. An empty config never snapshots.
+func PolicyFromConfig(cfg config.SnapshotPolicyConfig) SnapshotPolicy {
+	var policies []SnapshotPolicy
+
+	if cfg.EveryEvents > 0 {
+		policies = append(policies, EveryNEvents(cfg.EveryEvents))
+	}
+	if cfg.ReplayLongerThan > 0 {
+		policies = append(policies, ReplayLongerThan(cfg.ReplayLongerThan))
+	}
+	if len(cfg.OnEventTypes) > 0 {
+		eventTypes := make([]events.EventType, len(cfg.OnEventTypes))
+		for i, eventType := range cfg.OnEventTypes {
+			eventTypes[i] = events.EventType(eventType)
+		}
+		policies = append(policies, OnEventTypes(eventTypes...))
+	}
+	if cfg.StateSizeAbove > 0 {
+		policies = append(policies, StateSizeAbove(cfg.StateSizeAbove))
+	}
+	if cfg.EveryInterval > 0 {
+		policies = append(policies, EveryInterval(cfg.EveryInterval))
+	}
+
+	if len(policies) == 0 {
+		return NeverSnapshot()
+	}
+	return AnyOf(policies...)
+}
+
+// AnyOf snapshots when at least one of the given policies asks for it
+func AnyOf(policies ...SnapshotPolicy) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(ctx SnapshotContext) bool {
+		for _, policy := range policies {
+			if policy.ShouldSnapshot(ctx) {
+				return true
+			}
+		}
+		return false
+	})
+}
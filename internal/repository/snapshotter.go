@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// AsyncSnapshotter writes snapshots in the background so they do not add
+// latency to saves. Snapshots of the same aggregate always go to the same
+// worker, so they are written in the order the saves happened.
+type AsyncSnapshotter struct {
+	mu     sync.RWMutex
+	queues []chan func()
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewAsyncSnapshotter starts workers background writers with queueSize
+// pending snapshots each
+func NewAsyncSnapshotter(workers, queueSize int) *AsyncSnapshotter {
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	s := &AsyncSnapshotter{queues: make([]chan func(), workers)}
+
+	for i := range s.queues {
+
+		queue := make(chan func(), queueSize)
+		s.queues[i] = queue
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+
+	return s
+}
+
+// submit queues a snapshot job for an aggregate. It reports false, without
+// blocking, when the aggregate's queue is full or the snapshotter is closed.
+func (s *AsyncSnapshotter) submit(aggregateID uuid.UUID, job func()) bool {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return false
+	}
+
+	queue := s.queues[binary.BigEndian.Uint32(aggregateID[12:])%uint32(len(s.queues))]
+
+	select {
+	case queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting snapshots and waits until the queued ones are written
+// or ctx is done
+func (s *AsyncSnapshotter) Close(ctx context.Context) error {
+
+	s.mu.Lock()
+
+	if !s.closed {
+		s.closed = true
+
+		for _, queue := range s.queues {
+			close(queue)
+		}
+	}
+
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
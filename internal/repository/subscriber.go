@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+)
+
+// CommitNotifier signals that events may have been committed
+type CommitNotifier interface {
+	// Notify listens for commits until ctx is cancelled. The returned channel
+	// receives a value after commits and after reconnects, when commits may
+	// have been missed; signals are coalesced, so one value may stand for many
+	// commits. live reports whether commits are currently being signalled.
+	// Notify does not wait for a connection to be established.
+	Notify(ctx context.Context) (signals <-chan struct{}, live func() bool, err error)
+}
+
+// SubscriberConfig holds settings for event subscriptions
+type SubscriberConfig struct {
+	// BatchSize is the number of events read per query
+	BatchSize int
+	// PollInterval is how often the store is read while commits are not being signalled
+	PollInterval time.Duration
+}
+
+// DefaultSubscriberConfig returns default subscription settings
+func DefaultSubscriberConfig() SubscriberConfig {
+	return SubscriberConfig{
+		BatchSize:    DefaultBatchSize,
+		PollInterval: time.Second,
+	}
+}
+
+// StoreSubscriber implements EventSubscriber by reading the global log of an
+// EventStore whenever its CommitNotifier signals a commit, and by polling
+// while the notifier is down
+type StoreSubscriber struct {
+	store    EventStore
+	notifier CommitNotifier
+	cfg      SubscriberConfig
+}
+
+// NewStoreSubscriber creates a new StoreSubscriber. A nil notifier polls the store.
+func NewStoreSubscriber(store EventStore, notifier CommitNotifier, cfg SubscriberConfig) *StoreSubscriber {
+	defaults := DefaultSubscriberConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+
+	return &StoreSubscriber{
+		store:    store,
+		notifier: notifier,
+		cfg:      cfg,
+	}
+}
+
+// Subscribe implements the EventSubscriber interface
+func (s *StoreSubscriber) Subscribe(ctx context.Context, fromPosition int64, handler EventHandler) error {
+	return s.SubscribeBatches(ctx, fromPosition, func(batch []events.Event) error {
+		for _, event := range batch {
+			if err := handler(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SubscribeBatches implements the EventSubscriber interface
+func (s *StoreSubscriber) SubscribeBatches(ctx context.Context, fromPosition int64, handler BatchHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var signals <-chan struct{}
+	live := func() bool { return false }
+
+	// Listen before catching up so no commit falls between the two
+	if s.notifier != nil {
+		var err error
+		if signals, live, err = s.notifier.Notify(ctx); err != nil {
+			return err
+		}
+	}
+
+	position := fromPosition
+
+	catchUp := func() error {
+		for {
+			page, err := s.store.ReadAll(ctx, position, s.cfg.BatchSize)
+			if err != nil {
+				return err
+			}
+
+			// Events up to the position were delivered already, so a read
+			// that overlaps the previous one never repeats them
+			batch := make([]events.Event, 0, len(page))
+			for _, event := range page {
+				if event.Position > position {
+					batch = append(batch, event)
+					position = event.Position
+				}
+			}
+
+			if len(batch) > 0 {
+				if err := handler(batch); err != nil {
+					return err
+				}
+			}
+
+			if len(page) < s.cfg.BatchSize {
+				return handler(nil)
+			}
+		}
+	}
+
+	if err := catchUp(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-signals:
+			if err := catchUp(); err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			if live() {
+				continue
+			}
+			if err := catchUp(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/google/uuid"
+)
+
+// OrderRepository loads and saves order aggregates
+type OrderRepository interface {
+	Load(ctx context.Context, id uuid.UUID) (*order.Order, error)
+	Save(ctx context.Context, o *order.Order) error
+}
+
+// OrderCommandService executes order commands against the event store
+type OrderCommandService struct {
+	repo       OrderRepository
+	maxRetries int
+}
+
+// NewOrderCommandService creates a new order command service.
+// Commands that lose a concurrency race are reloaded and retried up to maxRetries times.
+func NewOrderCommandService(repo OrderRepository, maxRetries int) *OrderCommandService {
+
+	return &OrderCommandService{
+
+		repo:       repo,
+		maxRetries: maxRetries,
+	}
+}
+
+// CreateOrder starts a new draft order for a customer
+func (s *OrderCommandService) CreateOrder(ctx context.Context, customerID uuid.UUID) (*order.Order, error) {
+
+	o, err := order.NewOrder(customerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(ctx, o); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// AddItem adds a product line to a draft order
+func (s *OrderCommandService) AddItem(ctx context.Context, orderID, productID uuid.UUID, quantity int, unitPrice float64) (*order.Order, error) {
+
+	return s.execute(ctx, orderID, func(o *order.Order) error {
+		return o.AddItem(productID, quantity, unitPrice)
+	})
+}
+
+// RemoveItem removes a product line from a draft order
+func (s *OrderCommandService) RemoveItem(ctx context.Context, orderID, productID uuid.UUID) (*order.Order, error) {
+
+	return s.execute(ctx, orderID, func(o *order.Order) error {
+		return o.RemoveItem(productID)
+	})
+}
+
+// SubmitOrder submits a draft order
+func (s *OrderCommandService) SubmitOrder(ctx context.Context, orderID uuid.UUID) (*order.Order, error) {
+
+	return s.execute(ctx, orderID, func(o *order.Order) error {
+		return o.Submit()
+	})
+}
+
+// CancelOrder cancels a draft or submitted order
+func (s *OrderCommandService) CancelOrder(ctx context.Context, orderID uuid.UUID, reason string) (*order.Order, error) {
+
+	return s.execute(ctx, orderID, func(o *order.Order) error {
+		return o.Cancel(reason)
+	})
+}
+
+// execute loads the order, runs the command and saves the resulting events.
+// On a concurrency conflict the whole cycle is repeated against the fresh stream,
+// so the command is re-validated against state it has not seen before.
+func (s *OrderCommandService) execute(ctx context.Context, orderID uuid.UUID, command func(o *order.Order) error) (*order.Order, error) {
+
+	for attempt := 0; ; attempt++ {
+
+		o, err := s.repo.Load(ctx, orderID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := command(o); err != nil {
+			return nil, err
+		}
+
+		err = s.repo.Save(ctx, o)
+
+		if err == nil {
+			return o, nil
+		}
+
+		if !errors.Is(err, repository.ErrConcurrencyConflict) || attempt >= s.maxRetries {
+			return nil, err
+		}
+	}
+}
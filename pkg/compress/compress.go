@@ -0,0 +1,164 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrUnsupported is returned for algorithms without a registered codec
+var ErrUnsupported = errors.New("unsupported compression")
+
+// Algorithm names a compression algorithm
+type Algorithm string
+
+const (
+	// None stores payloads as they are
+	None Algorithm = "none"
+	// Gzip compresses payloads with gzip
+	Gzip Algorithm = "gzip"
+	// Zstd compresses payloads with Zstandard
+	Zstd Algorithm = "zstd"
+)
+
+// Codec compresses and decompresses payloads with one algorithm
+type Codec interface {
+	Algorithm() Algorithm
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	mu     sync.RWMutex
+	codecs = map[Algorithm]Codec{
+		None: noneCodec{},
+		Gzip: gzipCodec{level: gzip.DefaultCompression},
+		Zstd: &zstdCodec{},
+	}
+)
+
+// Register makes a codec available under its algorithm, replacing any
+// codec registered before
+func Register(codec Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	codecs[codec.Algorithm()] = codec
+}
+
+// CodecFor returns the codec of an algorithm. An empty algorithm means None.
+func CodecFor(algorithm Algorithm) (Codec, error) {
+	if algorithm == "" {
+		algorithm = None
+	}
+
+	mu.RLock()
+	codec, ok := codecs[algorithm]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, algorithm)
+	}
+
+	return codec, nil
+}
+
+// noneCodec leaves payloads unchanged
+type noneCodec struct{}
+
+func (noneCodec) Algorithm() Algorithm {
+	return None
+}
+
+func (noneCodec) Compress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (noneCodec) Decompress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// gzipCodec compresses payloads with gzip at a fixed level
+type gzipCodec struct {
+	level int
+}
+
+func (gzipCodec) Algorithm() Algorithm {
+	return Gzip
+}
+
+func (c gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// zstdCodec compresses payloads with Zstandard. Its encoder and decoder are
+// created on first use and shared, as EncodeAll and DecodeAll are safe for
+// concurrent use.
+type zstdCodec struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func (*zstdCodec) Algorithm() Algorithm {
+	return Zstd
+}
+
+func (c *zstdCodec) init() error {
+	c.once.Do(func() {
+		c.encoder, c.err = zstd.NewWriter(nil)
+		if c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil)
+	})
+
+	return c.err
+}
+
+func (c *zstdCodec) Compress(data []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCodec) Decompress(data []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+
+	return c.decoder.DecodeAll(data, nil)
+}
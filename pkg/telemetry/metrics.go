@@ -0,0 +1,335 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultRegistry is the registry served by the services' metrics endpoints
+var DefaultRegistry = NewRegistry()
+
+// metric is a collector that renders itself in the Prometheus text format
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metrics and exposes them in the Prometheus text format
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m, returning the metric already registered under its name
+// so packages can declare the same metric without coordinating
+func (r *Registry) register(m metric) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[m.name()]; ok {
+		return existing
+	}
+
+	r.metrics[m.name()] = m
+	return m
+}
+
+// Write writes every metric in the Prometheus text format, sorted by name
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name() < metrics[j].name()
+	})
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Handler serves the registry for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	})
+}
+
+// vec stores one value per combination of label values
+type vec[T any] struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*T
+	keys   map[string][]string
+}
+
+func newVec[T any](name, help string, labels []string) *vec[T] {
+	return &vec[T]{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		values:     make(map[string]*T),
+		keys:       make(map[string][]string),
+	}
+}
+
+func (v *vec[T]) name() string {
+	return v.metricName
+}
+
+// with returns the value for labelValues, creating it with create if needed.
+// Missing label values are empty and extra ones are ignored.
+func (v *vec[T]) with(labelValues []string, create func() *T) *T {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	value, ok := v.values[key]
+	if !ok {
+		value = create()
+		v.values[key] = value
+		v.keys[key] = values
+	}
+
+	return value
+}
+
+// each calls fn for every series, sorted by label values
+func (v *vec[T]) each(fn func(labelValues []string, value *T)) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	v.mu.Unlock()
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v.mu.Lock()
+		labelValues, value := v.keys[key], v.values[key]
+		v.mu.Unlock()
+
+		fn(labelValues, value)
+	}
+}
+
+// header writes the HELP and TYPE lines of a metric
+func (v *vec[T]) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.metricName, v.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.metricName, kind)
+}
+
+// labelPairs renders label names and values, plus any extra pairs, as {a="b"}
+func labelPairs(names, values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+"="+strconv.Quote(extra[i+1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// floatValue is a float64 safe for concurrent updates
+type floatValue struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (f *floatValue) add(delta float64) {
+	f.mu.Lock()
+	f.value += delta
+	f.mu.Unlock()
+}
+
+func (f *floatValue) set(value float64) {
+	f.mu.Lock()
+	f.value = value
+	f.mu.Unlock()
+}
+
+func (f *floatValue) get() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value
+}
+
+// Counter is a monotonically increasing value per label combination
+type Counter struct {
+	*vec[floatValue]
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return r.register(&Counter{vec: newVec[floatValue](name, help, labels)}).(*Counter)
+}
+
+// Inc adds one to the series of labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series of labelValues
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.with(labelValues, func() *floatValue { return &floatValue{} }).add(delta)
+}
+
+// Value returns the current value of the series of labelValues
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.with(labelValues, func() *floatValue { return &floatValue{} }).get()
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.header(w, "counter")
+	c.each(func(labelValues []string, value *floatValue) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, labelPairs(c.labels, labelValues), formatFloat(value.get()))
+	})
+}
+
+// Gauge is a value that can go up and down per label combination
+type Gauge struct {
+	*vec[floatValue]
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return r.register(&Gauge{vec: newVec[floatValue](name, help, labels)}).(*Gauge)
+}
+
+// Set sets the series of labelValues to value
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.with(labelValues, func() *floatValue { return &floatValue{} }).set(value)
+}
+
+// Add adds delta, which may be negative, to the series of labelValues
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.with(labelValues, func() *floatValue { return &floatValue{} }).add(delta)
+}
+
+// Value returns the current value of the series of labelValues
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.with(labelValues, func() *floatValue { return &floatValue{} }).get()
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.header(w, "gauge")
+	g.each(func(labelValues []string, value *floatValue) {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, labelPairs(g.labels, labelValues), formatFloat(value.get()))
+	})
+}
+
+// histogramValue holds the cumulative bucket counts of one series
+type histogramValue struct {
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations in cumulative buckets per label combination
+type Histogram struct {
+	*vec[histogramValue]
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds,
+// which must be sorted in increasing order
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return r.register(&Histogram{
+		vec:     newVec[histogramValue](name, help, labels),
+		buckets: buckets,
+	}).(*Histogram)
+}
+
+// Observe records value in the series of labelValues
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	series := h.with(labelValues, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(h.buckets))}
+	})
+
+	series.mu.Lock()
+	defer series.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+// Count returns the number of observations in the series of labelValues
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	series := h.with(labelValues, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(h.buckets))}
+	})
+
+	series.mu.Lock()
+	defer series.mu.Unlock()
+	return series.count
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.header(w, "histogram")
+	h.each(func(labelValues []string, series *histogramValue) {
+		series.mu.Lock()
+		defer series.mu.Unlock()
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, labelPairs(h.labels, labelValues, "le", formatFloat(bound)), series.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, labelPairs(h.labels, labelValues, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, labelPairs(h.labels, labelValues), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, labelPairs(h.labels, labelValues), series.count)
+	})
+}
+
+// ExponentialBuckets returns count bucket bounds starting at start,
+// each factor times the previous one
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
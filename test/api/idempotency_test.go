@@ -0,0 +1,193 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyStore implements repository.IdempotencyStore interface for testing
+type MockIdempotencyStore struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyStore) Reserve(ctx context.Context, key, requestHash string, window time.Duration) (repository.IdempotencyRecord, bool, error) {
+	args := m.Called(ctx, key, requestHash, window)
+	return args.Get(0).(repository.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyStore) Complete(ctx context.Context, key, token string, statusCode int, contentType string, response []byte) error {
+	args := m.Called(ctx, key, token, statusCode, contentType, response)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyStore) Release(ctx context.Context, key, token string) error {
+	args := m.Called(ctx, key, token)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newRouter(store repository.IdempotencyStore, status int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/orders", api.Idempotency(store, time.Hour), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"id": "order-1"})
+	})
+	return r
+}
+
+func post(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(api.HeaderIdempotencyKey, key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_StoresFirstResponse(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusCreated, &calls)
+
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Return(repository.IdempotencyRecord{Token: "token-1"}, true, nil)
+	store.On("Complete", mock.Anything, "key-1", "token-1", http.StatusCreated, "application/json; charset=utf-8", []byte(`{"id":"order-1"}`)).
+		Return(nil)
+
+	w := post(r, "key-1", `{"customerId":"c1"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, w.Header().Get(api.HeaderIdempotentReplayed))
+	store.AssertExpectations(t)
+}
+
+func TestIdempotency_ReplaysCompletedResponse(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusCreated, &calls)
+
+	var hash string
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Run(func(args mock.Arguments) { hash = args.String(2) }).
+		Return(repository.IdempotencyRecord{Token: "token-1"}, true, nil).Once()
+	store.On("Complete", mock.Anything, "key-1", "token-1", http.StatusCreated, mock.Anything, mock.Anything).Return(nil)
+
+	post(r, "key-1", `{"customerId":"c1"}`)
+
+	store.On("Reserve", mock.Anything, "key-1", hash, time.Hour).
+		Return(repository.IdempotencyRecord{
+			Key:         "key-1",
+			RequestHash: hash,
+			Completed:   true,
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Response:    []byte(`{"id":"order-1"}`),
+		}, false, nil).Once()
+
+	w := post(r, "key-1", `{"customerId":"c1"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"id":"order-1"}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(api.HeaderIdempotentReplayed))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_RejectsReusedKey(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusCreated, &calls)
+
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Return(repository.IdempotencyRecord{Key: "key-1", RequestHash: "other", Completed: true}, false, nil)
+
+	w := post(r, "key-1", `{"customerId":"c2"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotency_ReleasesKeyOnServerError(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusInternalServerError, &calls)
+
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Return(repository.IdempotencyRecord{Token: "token-1"}, true, nil)
+	store.On("Release", mock.Anything, "key-1", "token-1").Return(nil)
+
+	w := post(r, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotency_ReleasesKeyOnConcurrencyConflict(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/orders", api.Idempotency(store, time.Hour), func(c *gin.Context) {
+		_ = c.Error(repository.ErrConcurrencyConflict)
+		c.JSON(http.StatusConflict, gin.H{"title": "Concurrent modification"})
+	})
+
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Return(repository.IdempotencyRecord{Token: "token-1"}, true, nil)
+	store.On("Release", mock.Anything, "key-1", "token-1").Return(nil)
+
+	w := post(r, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotency_StoresStateConflict(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusConflict, &calls)
+
+	store.On("Reserve", mock.Anything, "key-1", mock.AnythingOfType("string"), time.Hour).
+		Return(repository.IdempotencyRecord{Token: "token-1"}, true, nil)
+	store.On("Complete", mock.Anything, "key-1", "token-1", http.StatusConflict, mock.Anything, mock.Anything).Return(nil)
+
+	w := post(r, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	store.AssertExpectations(t)
+	store.AssertNotCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotency_PassesThroughWithoutKey(t *testing.T) {
+	store := new(MockIdempotencyStore)
+	calls := 0
+	r := newRouter(store, http.StatusCreated, &calls)
+
+	w := post(r, "", `{}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, calls)
+	store.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
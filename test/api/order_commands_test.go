@@ -0,0 +1,280 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOrderRepository implements services.OrderRepository interface for testing
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Load(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	args := m.Called(ctx, id)
+	if load, ok := args.Get(0).(func() *order.Order); ok {
+		return load(), args.Error(1)
+	}
+	o, _ := args.Get(0).(*order.Order)
+	return o, args.Error(1)
+}
+
+func (m *MockOrderRepository) Save(ctx context.Context, o *order.Order) error {
+	args := m.Called(ctx, o)
+	return args.Error(0)
+}
+
+func newCommandRouter(repo services.OrderRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	api.NewOrderCommandHandlers(services.NewOrderCommandService(repo, 2)).Register(r.Group("/api"))
+	return r
+}
+
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeProblem reads an RFC 7807 response body
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) api.Problem {
+	t.Helper()
+
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem api.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, w.Code, problem.Status)
+	return problem
+}
+
+// draftOrder returns a stored draft order holding one item of product
+func draftOrder(t *testing.T, product uuid.UUID) *order.Order {
+	t.Helper()
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	if product != uuid.Nil {
+		require.NoError(t, o.AddItem(product, 1, 10))
+	}
+	o.MarkCommitted()
+	return o
+}
+
+func TestOrderCommands_CreateOrder(t *testing.T) {
+	repo := new(MockOrderRepository)
+	repo.On("Save", mock.Anything, mock.AnythingOfType("*order.Order")).Return(nil)
+
+	customerID := uuid.New()
+	w := serve(newCommandRouter(repo), http.MethodPost, "/api/orders", `{"customerId":"`+customerID.String()+`"}`)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, customerID.String(), body["customerId"])
+	assert.Equal(t, string(order.StatusDraft), body["status"])
+}
+
+func TestOrderCommands_ValidatesRequests(t *testing.T) {
+	orderID := uuid.New().String()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		title  string
+	}{
+		{"missing customer", http.MethodPost, "/api/orders", `{}`, "Invalid request body"},
+		{"malformed body", http.MethodPost, "/api/orders", `{"customerId":`, "Invalid request body"},
+		{"invalid customer", http.MethodPost, "/api/orders", `{"customerId":"nope"}`, "Invalid customer ID"},
+		{"invalid order id", http.MethodPost, "/api/orders/nope/submit", "", "Invalid id"},
+		{"zero quantity", http.MethodPost, "/api/orders/" + orderID + "/items", `{"productId":"` + uuid.New().String() + `","quantity":0,"unitPrice":1}`, "Invalid request body"},
+		{"negative price", http.MethodPost, "/api/orders/" + orderID + "/items", `{"productId":"` + uuid.New().String() + `","quantity":1,"unitPrice":-1}`, "Invalid request body"},
+		{"invalid product", http.MethodPost, "/api/orders/" + orderID + "/items", `{"productId":"nope","quantity":1}`, "Invalid product ID"},
+		{"invalid product path", http.MethodDelete, "/api/orders/" + orderID + "/items/nope", "", "Invalid productId"},
+		{"malformed cancel body", http.MethodPost, "/api/orders/" + orderID + "/cancel", `{"reason":`, "Invalid request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No command may reach the repository
+			repo := new(MockOrderRepository)
+
+			w := serve(newCommandRouter(repo), tt.method, tt.path, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.title, decodeProblem(t, w).Title)
+			repo.AssertNotCalled(t, "Load", mock.Anything, mock.Anything)
+			repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestOrderCommands_MapsErrorsToProblems(t *testing.T) {
+	product := uuid.New()
+
+	submitted := draftOrder(t, product)
+	require.NoError(t, submitted.Submit())
+	submitted.MarkCommitted()
+
+	conflict := &repository.ConcurrencyConflictError{AggregateType: order.AggregateType, ExpectedVersion: 1, ActualVersion: 2}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		loaded  *order.Order
+		loadErr error
+		saveErr error
+		status  int
+		title   string
+	}{
+		{
+			name: "order not found", method: http.MethodPost, path: "/submit",
+			loadErr: repository.ErrAggregateNotFound,
+			status:  http.StatusNotFound, title: "Order not found",
+		},
+		{
+			name: "item not found", method: http.MethodDelete, path: "/items/" + uuid.New().String(),
+			loaded: draftOrder(t, product),
+			status: http.StatusNotFound, title: "Item not found",
+		},
+		{
+			name: "submit without items", method: http.MethodPost, path: "/submit",
+			loaded: draftOrder(t, uuid.Nil),
+			status: http.StatusUnprocessableEntity, title: "Order has no items",
+		},
+		{
+			name: "add product twice", method: http.MethodPost, path: "/items",
+			body:   `{"productId":"` + product.String() + `","quantity":1,"unitPrice":2}`,
+			loaded: draftOrder(t, product),
+			status: http.StatusConflict, title: "Item already in order",
+		},
+		{
+			name: "add to submitted order", method: http.MethodPost, path: "/items",
+			body:   `{"productId":"` + uuid.New().String() + `","quantity":1,"unitPrice":2}`,
+			loaded: submitted,
+			status: http.StatusConflict, title: "Order state conflict",
+		},
+		{
+			name: "concurrent modification", method: http.MethodPost, path: "/items",
+			body:   `{"productId":"` + uuid.New().String() + `","quantity":1,"unitPrice":2}`,
+			loaded: draftOrder(t, product), saveErr: conflict,
+			status: http.StatusConflict, title: "Concurrent modification",
+		},
+		{
+			name: "store failure", method: http.MethodPost, path: "/cancel",
+			loaded: draftOrder(t, product), saveErr: errors.New("connection reset"),
+			status: http.StatusInternalServerError, title: "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderID := uuid.New()
+
+			repo := new(MockOrderRepository)
+			// Every load, including those of retries, sees the stored state
+			load := func() *order.Order {
+				if tt.loaded == nil {
+					return nil
+				}
+				o := *tt.loaded
+				o.Items = append([]order.OrderItem(nil), tt.loaded.Items...)
+				return &o
+			}
+
+			repo.On("Load", mock.Anything, orderID).Return(load, tt.loadErr)
+			repo.On("Save", mock.Anything, mock.Anything).Return(tt.saveErr)
+
+			w := serve(newCommandRouter(repo), tt.method, "/api/orders/"+orderID.String()+tt.path, tt.body)
+
+			assert.Equal(t, tt.status, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.title, problem.Title)
+
+			// Internal errors are not leaked to clients
+			if tt.status == http.StatusInternalServerError {
+				assert.Empty(t, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestOrderCommands_RetriesConcurrencyConflicts(t *testing.T) {
+	orderID := uuid.New()
+	product := uuid.New()
+
+	repo := new(MockOrderRepository)
+	repo.On("Load", mock.Anything, orderID).Return(draftOrder(t, product), nil).Once()
+	repo.On("Load", mock.Anything, orderID).Return(draftOrder(t, product), nil).Once()
+	repo.On("Save", mock.Anything, mock.Anything).Return(&repository.ConcurrencyConflictError{}).Once()
+	repo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+
+	w := serve(newCommandRouter(repo), http.MethodPost, "/api/orders/"+orderID.String()+"/submit", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCommands_CancelAcceptsEmptyBody(t *testing.T) {
+	orderID := uuid.New()
+
+	repo := new(MockOrderRepository)
+	repo.On("Load", mock.Anything, orderID).Return(draftOrder(t, uuid.New()), nil)
+	repo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	w := serve(newCommandRouter(repo), http.MethodPost, "/api/orders/"+orderID.String()+"/cancel", "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, string(order.StatusCancelled), body["status"])
+}
+
+func TestOrderCommands_CancelAcceptsEmptyChunkedBody(t *testing.T) {
+	orderID := uuid.New()
+
+	repo := new(MockOrderRepository)
+	repo.On("Load", mock.Anything, orderID).Return(draftOrder(t, uuid.New()), nil)
+	repo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	// A chunked request does not know its length up front
+	req := httptest.NewRequest(http.MethodPost, "/api/orders/"+orderID.String()+"/cancel", strings.NewReader(""))
+	req.ContentLength = -1
+	req.TransferEncoding = []string{"chunked"}
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	newCommandRouter(repo).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
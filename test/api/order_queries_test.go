@@ -0,0 +1,239 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOrderViewStore implements repository.OrderViewStore interface for testing
+type MockOrderViewStore struct {
+	mock.Mock
+}
+
+func (m *MockOrderViewStore) GetOrder(ctx context.Context, id uuid.UUID) (repository.OrderView, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(repository.OrderView), args.Error(1)
+}
+
+func (m *MockOrderViewStore) ListOrders(ctx context.Context, query repository.OrderQuery) (repository.OrderPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(repository.OrderPage), args.Error(1)
+}
+
+// MockOrderLoader implements api.OrderLoader interface for testing
+type MockOrderLoader struct {
+	mock.Mock
+}
+
+func (m *MockOrderLoader) LoadAt(ctx context.Context, id uuid.UUID, at repository.PointInTime) (*order.Order, error) {
+	args := m.Called(ctx, id, at)
+	o, _ := args.Get(0).(*order.Order)
+	return o, args.Error(1)
+}
+
+func newQueryRouter(views repository.OrderViewStore, orders api.OrderLoader) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	api.NewOrderQueryHandlers(views, nil, orders).Register(r.Group("/api"))
+	return r
+}
+
+func TestOrderQueries_GetOrder(t *testing.T) {
+	orderID := uuid.New()
+
+	views := new(MockOrderViewStore)
+	views.On("GetOrder", mock.Anything, orderID).
+		Return(repository.OrderView{ID: orderID, Status: string(order.StatusDraft), Version: 2}, nil)
+
+	w := serve(newQueryRouter(views, nil), http.MethodGet, "/api/orders/"+orderID.String(), "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var view repository.OrderView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, orderID, view.ID)
+	assert.Equal(t, int64(2), view.Version)
+}
+
+func TestOrderQueries_GetOrderNotFound(t *testing.T) {
+	views := new(MockOrderViewStore)
+	views.On("GetOrder", mock.Anything, mock.Anything).Return(repository.OrderView{}, repository.ErrOrderViewNotFound)
+
+	w := serve(newQueryRouter(views, nil), http.MethodGet, "/api/orders/"+uuid.New().String(), "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Order not found", problem.Title)
+	assert.NotEmpty(t, problem.Detail)
+}
+
+func TestOrderQueries_GetOrderAsOf(t *testing.T) {
+	orderID := uuid.New()
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	o.MarkCommitted()
+
+	asOf := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	orders := new(MockOrderLoader)
+	orders.On("LoadAt", mock.Anything, orderID, repository.AtVersion(3)).Return(o, nil)
+	orders.On("LoadAt", mock.Anything, orderID, repository.AtTime(asOf)).Return(nil, repository.ErrAggregateNotFound)
+
+	r := newQueryRouter(new(MockOrderViewStore), orders)
+
+	w := serve(r, http.MethodGet, "/api/orders/"+orderID.String()+"?asOf=3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var view repository.OrderView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, o.ID, view.ID)
+
+	w = serve(r, http.MethodGet, "/api/orders/"+orderID.String()+"?asOf="+asOf.Format(time.RFC3339), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Order not found", decodeProblem(t, w).Title)
+
+	for _, invalid := range []string{"0", "-1", "yesterday"} {
+		w = serve(r, http.MethodGet, "/api/orders/"+orderID.String()+"?asOf="+invalid, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, invalid)
+		assert.Equal(t, "Invalid asOf", decodeProblem(t, w).Title)
+	}
+}
+
+func TestOrderQueries_ListOrdersParsesParameters(t *testing.T) {
+	customerID := uuid.New()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	minTotal := 10.5
+
+	tests := []struct {
+		name  string
+		path  string
+		query repository.OrderQuery
+	}{
+		{
+			name: "defaults",
+			path: "/api/orders",
+			query: repository.OrderQuery{
+				SortBy:     repository.SortByCreatedAt,
+				Descending: true,
+			},
+		},
+		{
+			name: "every filter",
+			path: "/api/orders?sort=totalAmount&order=asc&status=" + string(order.StatusSubmitted) + "&from=" + from.Format(time.RFC3339) +
+				"&minTotal=10.5&limit=5&cursor=next",
+			query: repository.OrderQuery{
+				Status:   string(order.StatusSubmitted),
+				From:     from,
+				MinTotal: &minTotal,
+				SortBy:   repository.SortByTotalAmount,
+				Limit:    5,
+				Cursor:   "next",
+			},
+		},
+		{
+			name: "customer orders",
+			path: "/api/customers/" + customerID.String() + "/orders?sort=updatedAt",
+			query: repository.OrderQuery{
+				CustomerID: customerID,
+				SortBy:     repository.SortByUpdatedAt,
+				Descending: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := repository.OrderPage{
+				Orders:     []repository.OrderView{{ID: uuid.New()}},
+				NextCursor: "after",
+			}
+
+			views := new(MockOrderViewStore)
+			views.On("ListOrders", mock.Anything, tt.query).Return(page, nil)
+
+			w := serve(newQueryRouter(views, nil), http.MethodGet, tt.path, "")
+
+			require.Equal(t, http.StatusOK, w.Code)
+			views.AssertExpectations(t)
+
+			var body repository.OrderPage
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Len(t, body.Orders, 1)
+			assert.Equal(t, "after", body.NextCursor)
+		})
+	}
+}
+
+func TestOrderQueries_ListOrdersRejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		query string
+		title string
+	}{
+		{"sort=name", "Invalid sort"},
+		{"order=up", "Invalid order"},
+		{"status=shipped", "Invalid status"},
+		{"from=today", "Invalid from"},
+		{"to=tomorrow", "Invalid to"},
+		{"minTotal=lots", "Invalid minTotal"},
+		{"limit=0", "Invalid limit"},
+		{"limit=-3", "Invalid limit"},
+		{"limit=many", "Invalid limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			views := new(MockOrderViewStore)
+
+			w := serve(newQueryRouter(views, nil), http.MethodGet, "/api/orders?"+tt.query, "")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.title, decodeProblem(t, w).Title)
+			views.AssertNotCalled(t, "ListOrders", mock.Anything, mock.Anything)
+		})
+	}
+
+	w := serve(newQueryRouter(new(MockOrderViewStore), nil), http.MethodGet, "/api/customers/nope/orders", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid id", decodeProblem(t, w).Title)
+}
+
+func TestOrderQueries_ListOrdersMapsStoreErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		title  string
+	}{
+		{repository.ErrInvalidCursor, http.StatusBadRequest, "Invalid cursor"},
+		{errors.New("connection reset"), http.StatusInternalServerError, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			views := new(MockOrderViewStore)
+			views.On("ListOrders", mock.Anything, mock.Anything).Return(repository.OrderPage{}, tt.err)
+
+			w := serve(newQueryRouter(views, nil), http.MethodGet, "/api/orders?cursor=stale", "")
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.title, decodeProblem(t, w).Title)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/api"
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func traceRequest(headers map[string]string) (events.Trace, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	var trace events.Trace
+
+	r := gin.New()
+	r.POST("/orders", api.Tracing(), func(c *gin.Context) {
+		trace = events.TraceFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return trace, w
+}
+
+func TestTracing_ReadsHeaders(t *testing.T) {
+	trace, w := traceRequest(map[string]string{
+		api.HeaderCorrelationID: "checkout-42",
+		api.HeaderRequestID:     "request-1",
+		api.HeaderUserID:        "user-7",
+		api.HeaderTraceParent:   "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	})
+
+	assert.Equal(t, events.Trace{
+		CorrelationID: "checkout-42",
+		CausationID:   "request-1",
+		UserID:        "user-7",
+		TraceID:       "4bf92f3577b34da6a3ce929d0e0e4736",
+	}, trace)
+	assert.Equal(t, "checkout-42", w.Header().Get(api.HeaderCorrelationID))
+}
+
+func TestTracing_GeneratesMissingIDs(t *testing.T) {
+	trace, w := traceRequest(map[string]string{
+		api.HeaderTraceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+	})
+
+	assert.NotEmpty(t, trace.CausationID)
+	assert.Equal(t, trace.CausationID, trace.CorrelationID)
+	assert.Empty(t, trace.UserID)
+	assert.Empty(t, trace.TraceID)
+	assert.Equal(t, trace.CorrelationID, w.Header().Get(api.HeaderCorrelationID))
+	assert.Equal(t, trace.CausationID, w.Header().Get(api.HeaderRequestID))
+}
@@ -0,0 +1,369 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRemote is an in-memory stand-in for Redis that counts reads
+type memoryRemote struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	versions map[string]int64
+	lists    map[string][][]byte
+	reads    int
+}
+
+func newMemoryRemote() *memoryRemote {
+	return &memoryRemote{values: make(map[string][]byte), versions: make(map[string]int64), lists: make(map[string][][]byte)}
+}
+
+func (m *memoryRemote) readCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reads
+}
+
+func (m *memoryRemote) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	value, ok := m.values[key]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (m *memoryRemote) Set(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryRemote) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryRemote) BatchGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	for _, key := range keys {
+		if value, err := m.Get(ctx, key); err == nil {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryRemote) BatchSet(ctx context.Context, pairs map[string][]byte) error {
+	for key, value := range pairs {
+		m.Set(ctx, key, value)
+	}
+	return nil
+}
+
+func (m *memoryRemote) BatchDelete(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		m.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (m *memoryRemote) SetVersioned(ctx context.Context, key string, version int64, value []byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.versions[key]; ok && current > version {
+		return false, nil
+	}
+	m.values[key] = value
+	m.versions[key] = version
+	return true, nil
+}
+
+func (m *memoryRemote) InvalidateVersioned(ctx context.Context, key string, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, ok := m.versions[key]; ok && current >= version {
+		return nil
+	}
+	delete(m.values, key)
+	m.versions[key] = version
+	return nil
+}
+
+func (m *memoryRemote) GetEventStream(ctx context.Context, aggregateType, aggregateID string) ([]byte, error) {
+	return m.Get(ctx, "events:"+aggregateType+":"+aggregateID)
+}
+
+func (m *memoryRemote) SetEventStream(ctx context.Context, aggregateType, aggregateID string, value []byte) error {
+	return m.Set(ctx, "events:"+aggregateType+":"+aggregateID, value)
+}
+
+func (m *memoryRemote) BatchSetEventStreams(ctx context.Context, streams map[string]map[string][]byte) error {
+	return nil
+}
+
+func (m *memoryRemote) GetEventList(ctx context.Context, aggregateType, aggregateID string) ([][]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	values, ok := m.lists[aggregateType+":"+aggregateID]
+	if !ok {
+		return nil, false, cache.ErrCacheMiss
+	}
+	return values, true, nil
+}
+
+func (m *memoryRemote) SetEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lists[aggregateType+":"+aggregateID] = values
+	return nil
+}
+
+func (m *memoryRemote) FillEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := aggregateType + ":" + aggregateID
+	if len(m.lists[key]) > len(values) {
+		return false, nil
+	}
+	m.lists[key] = values
+	return true, nil
+}
+
+func (m *memoryRemote) AppendEventList(ctx context.Context, aggregateType, aggregateID string, expectedLen int64, values [][]byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := aggregateType + ":" + aggregateID
+	if int64(len(m.lists[key])) != expectedLen {
+		delete(m.lists, key)
+		return false, nil
+	}
+	m.lists[key] = append(append([][]byte(nil), m.lists[key]...), values...)
+	return true, nil
+}
+
+func (m *memoryRemote) TryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (m *memoryRemote) Unlock(ctx context.Context, key, token string) error {
+	return nil
+}
+
+func (m *memoryRemote) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (m *memoryRemote) Close() error {
+	return nil
+}
+
+// memoryBus delivers invalidations to every other subscriber
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers map[*memoryBusClient]func([]string)
+}
+
+// memoryBusClient is one replica's connection to a memoryBus
+type memoryBusClient struct {
+	bus *memoryBus
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{subscribers: make(map[*memoryBusClient]func([]string))}
+}
+
+func (b *memoryBus) client() *memoryBusClient {
+	return &memoryBusClient{bus: b}
+}
+
+func (b *memoryBus) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func (c *memoryBusClient) Publish(ctx context.Context, keys []string) error {
+	c.bus.mu.Lock()
+	defer c.bus.mu.Unlock()
+	for client, onKeys := range c.bus.subscribers {
+		if client != c {
+			onKeys(keys)
+		}
+	}
+	return nil
+}
+
+func (c *memoryBusClient) Subscribe(ctx context.Context, onKeys func(keys []string), onReset func()) error {
+	c.bus.mu.Lock()
+	c.bus.subscribers[c] = onKeys
+	c.bus.mu.Unlock()
+
+	onReset()
+	<-ctx.Done()
+	return nil
+}
+
+func localConfig(maxEntries int, maxBytes int64) config.RedisConfig {
+	cfg := config.DefaultRedisConfig()
+	cfg.LocalMaxEntries = maxEntries
+	cfg.LocalMaxBytes = maxBytes
+	return cfg
+}
+
+func TestTieredCache_ServesRepeatedReadsLocally(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newMemoryRemote()
+	require.NoError(t, remote.Set(ctx, "k", []byte("v")))
+
+	tiered := cache.NewTieredCache(remote, newMemoryBus().client(), localConfig(10, 1024))
+	defer tiered.Close()
+
+	for i := 0; i < 3; i++ {
+		value, err := tiered.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v"), value)
+	}
+
+	assert.Equal(t, 1, remote.readCount())
+
+	_, err := tiered.Get(ctx, "missing")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+}
+
+func TestTieredCache_EvictsOverLimits(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newMemoryRemote()
+	tiered := cache.NewTieredCache(remote, newMemoryBus().client(), localConfig(2, 1024))
+	defer tiered.Close()
+
+	require.NoError(t, tiered.Set(ctx, "a", []byte("1")))
+	require.NoError(t, tiered.Set(ctx, "b", []byte("2")))
+	require.NoError(t, tiered.Set(ctx, "c", []byte("3")))
+
+	// "a" was the least recently used entry when "c" came in
+	_, err := tiered.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, remote.readCount())
+
+	_, err = tiered.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, 1, remote.readCount())
+
+	// Values larger than the byte limit are never kept locally
+	small := cache.NewTieredCache(remote, newMemoryBus().client(), localConfig(0, 8))
+	defer small.Close()
+
+	require.NoError(t, small.Set(ctx, "big", make([]byte, 64)))
+	_, err = small.Get(ctx, "big")
+	require.NoError(t, err)
+	assert.Equal(t, 2, remote.readCount())
+}
+
+func TestTieredCache_InvalidatesOtherReplicas(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newMemoryRemote()
+	bus := newMemoryBus()
+
+	first := cache.NewTieredCache(remote, bus.client(), localConfig(10, 1024))
+	defer first.Close()
+	second := cache.NewTieredCache(remote, bus.client(), localConfig(10, 1024))
+	defer second.Close()
+
+	require.Eventually(t, func() bool { return bus.count() == 2 }, time.Second, time.Millisecond)
+
+	require.NoError(t, first.SetEventList(ctx, "Order", "1", [][]byte{[]byte("e1")}))
+
+	values, fresh, err := second.GetEventList(ctx, "Order", "1")
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	assert.Len(t, values, 1)
+
+	// An append on one replica evicts the list on the other
+	appended, err := first.AppendEventList(ctx, "Order", "1", 1, [][]byte{[]byte("e2")})
+	require.NoError(t, err)
+	assert.True(t, appended)
+
+	values, _, err = second.GetEventList(ctx, "Order", "1")
+	require.NoError(t, err)
+	assert.Len(t, values, 2)
+
+	// The appending replica extended its own copy
+	reads := remote.readCount()
+	values, _, err = first.GetEventList(ctx, "Order", "1")
+	require.NoError(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, reads, remote.readCount())
+}
+
+func TestTieredCache_FillKeepsLongerList(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newMemoryRemote()
+	tiered := cache.NewTieredCache(remote, newMemoryBus().client(), localConfig(10, 1024))
+	defer tiered.Close()
+
+	filled, err := tiered.FillEventList(ctx, "Order", "1", [][]byte{[]byte("e1")})
+	require.NoError(t, err)
+	assert.True(t, filled)
+
+	// An event appended after a slower reader loaded the stream
+	appended, err := tiered.AppendEventList(ctx, "Order", "1", 1, [][]byte{[]byte("e2")})
+	require.NoError(t, err)
+	assert.True(t, appended)
+
+	filled, err = tiered.FillEventList(ctx, "Order", "1", [][]byte{[]byte("e1")})
+	require.NoError(t, err)
+	assert.False(t, filled)
+
+	values, _, err := tiered.GetEventList(ctx, "Order", "1")
+	require.NoError(t, err)
+	assert.Len(t, values, 2)
+}
+
+func TestTieredCache_RejectsSupersededVersions(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newMemoryRemote()
+	tiered := cache.NewTieredCache(remote, newMemoryBus().client(), localConfig(10, 1024))
+	defer tiered.Close()
+
+	written, err := tiered.SetVersioned(ctx, "k", 4, []byte("v4"))
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	// Version 5 is saved while version 4 is being written back elsewhere
+	require.NoError(t, tiered.InvalidateVersioned(ctx, "k", 5))
+
+	written, err = tiered.SetVersioned(ctx, "k", 4, []byte("v4"))
+	require.NoError(t, err)
+	assert.False(t, written)
+
+	_, err = tiered.Get(ctx, "k")
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	written, err = tiered.SetVersioned(ctx, "k", 5, []byte("v5"))
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	value, err := tiered.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v5"), value)
+}
@@ -0,0 +1,43 @@
+package compress_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/pkg/compress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"ProductID":"a","Quantity":1,"UnitPrice":10}`), 200)
+
+	for _, algorithm := range []compress.Algorithm{compress.Gzip, compress.Zstd} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			codec, err := compress.CodecFor(algorithm)
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, codec.Algorithm())
+
+			compressed, err := codec.Compress(payload)
+			require.NoError(t, err)
+			assert.Less(t, len(compressed), len(payload))
+
+			decompressed, err := codec.Decompress(compressed)
+			require.NoError(t, err)
+			assert.Equal(t, payload, decompressed)
+
+			_, err = codec.Decompress([]byte("not compressed"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	codec, err := compress.CodecFor("")
+	require.NoError(t, err)
+	assert.Equal(t, compress.None, codec.Algorithm())
+
+	_, err = compress.CodecFor("brotli")
+	assert.ErrorIs(t, err, compress.ErrUnsupported)
+}
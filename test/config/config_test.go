@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/pkg/compress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultConfig(), cfg)
+}
+
+func TestLoad_SnapshotCompression(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, "snapshots:\n  compression: gzip\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", cfg.Snapshots.Compression)
+
+	cfg, err = config.Load(writeConfig(t, "snapshots:\n  compression: zstd\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "zstd", cfg.Snapshots.Compression)
+
+	_, err = config.Load(writeConfig(t, "snapshots:\n  compression: brotli\n"))
+	assert.ErrorIs(t, err, compress.ErrUnsupported)
+}
+
+func TestLoad_EventPayloadFormat(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, "events:\n  payload_format: msgpack\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "msgpack", cfg.Events.PayloadFormat)
+
+	_, err = config.Load(writeConfig(t, "events:\n  payload_format: protobuf\n"))
+	assert.ErrorIs(t, err, events.ErrUnknownFormat)
+}
@@ -0,0 +1,60 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_ReportsFieldChanges(t *testing.T) {
+
+	customerID := uuid.New()
+	o, err := order.NewOrder(customerID)
+	require.NoError(t, err)
+
+	productID := uuid.New()
+	require.NoError(t, o.AddItem(productID, 2, 10))
+	require.NoError(t, o.RemoveItem(productID))
+	require.NoError(t, o.Cancel("customer called"))
+
+	history, err := order.History(o.UncommittedEvents())
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+
+	assert.Equal(t, []order.FieldChange{
+		{Field: order.FieldCustomerID, From: uuid.Nil, To: customerID},
+		{Field: order.FieldStatus, From: order.Status(""), To: order.StatusDraft},
+	}, history[0].Changes)
+
+	item := order.OrderItem{ProductID: productID, Quantity: 2, UnitPrice: 10}
+
+	assert.Equal(t, []order.FieldChange{
+		{Field: order.FieldItems, To: item},
+		{Field: order.FieldTotalAmount, From: 0.0, To: 20.0},
+	}, history[1].Changes)
+
+	assert.Equal(t, []order.FieldChange{
+		{Field: order.FieldItems, From: item},
+		{Field: order.FieldTotalAmount, From: 20.0, To: 0.0},
+	}, history[2].Changes)
+
+	assert.Equal(t, []order.FieldChange{
+		{Field: order.FieldStatus, From: order.StatusDraft, To: order.StatusCancelled},
+	}, history[3].Changes)
+
+	cancelled, ok := history[3].Payload.(events.OrderCancelledEvent)
+	require.True(t, ok)
+	assert.Equal(t, "customer called", cancelled.Reason)
+}
+
+func TestHistory_EmptyStream(t *testing.T) {
+
+	_, err := order.History(nil)
+	assert.ErrorIs(t, err, order.ErrNoHistory)
+}
@@ -0,0 +1,126 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder_RecordsUncommittedEvents(t *testing.T) {
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+
+	productID := uuid.New()
+	require.NoError(t, o.AddItem(productID, 2, 10.5))
+	require.NoError(t, o.Submit())
+
+	changes := o.UncommittedEvents()
+
+	assert.Len(t, changes, 3)
+	assert.Equal(t, events.OrderCreatedEventType, changes[0].EventType)
+	assert.Equal(t, events.OrderItemAddedEventType, changes[1].EventType)
+	assert.Equal(t, events.OrderSubmittedEventType, changes[2].EventType)
+
+	for i, event := range changes {
+		assert.Equal(t, order.AggregateType, event.AggregateType)
+		assert.Equal(t, o.ID, event.AggregateID)
+		assert.Equal(t, int64(i+1), event.Sequence)
+	}
+
+	assert.Equal(t, 3, o.Version)
+	assert.Equal(t, 21.0, o.TotalAmount)
+	assert.Equal(t, order.StatusSubmitted, o.Status)
+
+	o.MarkCommitted()
+	assert.Empty(t, o.UncommittedEvents())
+}
+
+func TestOrder_LoadFromHistory(t *testing.T) {
+
+	customerID := uuid.New()
+	o, err := order.NewOrder(customerID)
+	require.NoError(t, err)
+
+	keep, drop := uuid.New(), uuid.New()
+	require.NoError(t, o.AddItem(keep, 1, 5))
+	require.NoError(t, o.AddItem(drop, 3, 2))
+	require.NoError(t, o.RemoveItem(drop))
+
+	loaded, err := order.LoadFromHistory(o.UncommittedEvents())
+	require.NoError(t, err)
+
+	assert.Equal(t, o.ID, loaded.ID)
+	assert.Equal(t, customerID, loaded.CustomerID)
+	assert.Equal(t, order.StatusDraft, loaded.Status)
+	assert.Equal(t, []order.OrderItem{{ProductID: keep, Quantity: 1, UnitPrice: 5}}, loaded.Items)
+	assert.Equal(t, 5.0, loaded.TotalAmount)
+	assert.Equal(t, 4, loaded.Version)
+	assert.Empty(t, loaded.UncommittedEvents())
+}
+
+func TestOrder_CommandsRejectInvalidState(t *testing.T) {
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, o.Submit(), order.ErrOrderHasNoItems)
+	assert.ErrorIs(t, o.RemoveItem(uuid.New()), order.ErrItemNotFound)
+
+	require.NoError(t, o.Cancel("changed my mind"))
+
+	assert.ErrorIs(t, o.AddItem(uuid.New(), 1, 1), order.ErrOrderNotInDraftState)
+	assert.ErrorIs(t, o.Cancel("again"), order.ErrOrderCannotBeCancelled)
+
+	// Rejected commands must not record events
+	assert.Len(t, o.UncommittedEvents(), 2)
+}
+
+func TestOrder_LoadFromHistory_Empty(t *testing.T) {
+
+	_, err := order.LoadFromHistory(nil)
+	assert.ErrorIs(t, err, order.ErrNoHistory)
+}
+
+func TestOrder_AddItemRejectsDuplicateProduct(t *testing.T) {
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+
+	productID := uuid.New()
+	require.NoError(t, o.AddItem(productID, 1, 5))
+
+	assert.ErrorIs(t, o.AddItem(productID, 2, 5), order.ErrItemAlreadyAdded)
+	assert.Len(t, o.Items, 1)
+	assert.Len(t, o.UncommittedEvents(), 2)
+}
+
+func TestOrder_RemoveItemDropsEveryLineOfProduct(t *testing.T) {
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+
+	productID := uuid.New()
+	require.NoError(t, o.AddItem(productID, 1, 5))
+
+	// Streams written before duplicates were rejected can hold a product twice
+	history := o.UncommittedEvents()
+	duplicate, err := order.EventTypes().Encode(events.OrderItemAddedEvent{ProductID: productID, Quantity: 2, UnitPrice: 4})
+	require.NoError(t, err)
+	history = append(history, events.NewEvent(order.AggregateType, o.ID, duplicate.EventType, duplicate.EventVersion, 3, duplicate.Data, nil))
+
+	loaded, err := order.LoadFromHistory(history)
+	require.NoError(t, err)
+	require.Len(t, loaded.Items, 2)
+	assert.Equal(t, 13.0, loaded.TotalAmount)
+
+	require.NoError(t, loaded.RemoveItem(productID))
+	assert.Empty(t, loaded.Items)
+	assert.Zero(t, loaded.TotalAmount)
+}
@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrderTypeRegistry() *events.TypeRegistry {
+	registry := events.NewTypeRegistry()
+	registry.MustRegister(events.OrderItemAddedEventType, 1, events.OrderItemAddedEvent{})
+	registry.MustRegister(events.OrderCancelledEventType, 2, events.OrderCancelledEvent{})
+	return registry
+}
+
+func TestTypeRegistry_EncodeDecode(t *testing.T) {
+
+	registry := newOrderTypeRegistry()
+
+	payload := events.OrderItemAddedEvent{ProductID: uuid.New(), Quantity: 3, UnitPrice: 9.5}
+
+	event, err := registry.Encode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, events.OrderItemAddedEventType, event.EventType)
+	assert.Equal(t, 1, event.EventVersion)
+
+	decoded, err := registry.Decode(event)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+
+	typed, err := events.DecodeAs[events.OrderItemAddedEvent](registry, event)
+	require.NoError(t, err)
+	assert.Equal(t, payload, typed)
+
+	_, err = events.DecodeAs[events.OrderCancelledEvent](registry, event)
+	assert.ErrorIs(t, err, events.ErrInvalidPayload)
+
+	// Pointers encode like values
+	_, err = registry.Encode(&events.OrderCancelledEvent{Reason: "duplicate"})
+	require.NoError(t, err)
+}
+
+func TestTypeRegistry_UnknownTypes(t *testing.T) {
+
+	registry := newOrderTypeRegistry()
+
+	_, err := registry.Encode(events.OrderSubmittedEvent{})
+	assert.ErrorIs(t, err, events.ErrUnknownEventType)
+
+	event := events.NewEvent("Order", uuid.New(), events.OrderSubmittedEventType, 1, 1, []byte(`{}`), nil)
+
+	_, err = registry.Decode(event)
+	assert.ErrorIs(t, err, events.ErrUnknownEventType)
+	assert.ErrorIs(t, registry.Validate(event), events.ErrUnknownEventType)
+
+	assert.Error(t, registry.Register(events.OrderItemAddedEventType, 1, events.OrderItemRemovedEvent{}))
+	assert.Error(t, registry.Register(events.OrderSubmittedEventType, 1, "not a struct"))
+}
+
+func TestTypeRegistry_Validate(t *testing.T) {
+
+	registry := newOrderTypeRegistry()
+
+	valid := events.NewEvent("Order", uuid.New(), events.OrderItemAddedEventType, 1, 1, []byte(`{"Quantity":1,"UnitPrice":2}`), nil)
+	assert.NoError(t, registry.Validate(valid))
+
+	unknownField := valid
+	unknownField.Data = []byte(`{"Quantity":1,"Currency":"EUR"}`)
+	assert.ErrorIs(t, registry.Validate(unknownField), events.ErrInvalidPayload)
+
+	// Decode is lenient about fields it does not know
+	_, err := registry.Decode(unknownField)
+	assert.NoError(t, err)
+
+	wrongType := valid
+	wrongType.Data = []byte(`{"Quantity":"one"}`)
+	assert.ErrorIs(t, registry.Validate(wrongType), events.ErrInvalidPayload)
+
+	staleVersion := valid
+	staleVersion.EventType = events.OrderCancelledEventType
+	staleVersion.Data = []byte(`{"Reason":"late"}`)
+	assert.ErrorIs(t, registry.Validate(staleVersion), events.ErrInvalidPayload)
+}
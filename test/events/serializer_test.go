@@ -0,0 +1,96 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allFormats = []events.Format{events.FormatJSON, events.FormatMsgpack}
+
+func TestSerializers_RoundTrip(t *testing.T) {
+
+	created := events.OrderCreatedEvent{
+		CustomerID: uuid.New(),
+		CreatedAt:  time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	stream := []events.Event{
+		events.NewEvent("Order", uuid.New(), events.OrderItemAddedEventType, 1, 2, []byte(`{"Quantity":2}`), map[string]interface{}{"format": "json"}),
+	}
+
+	for _, format := range allFormats {
+		t.Run(string(format), func(t *testing.T) {
+
+			serializer, err := events.SerializerFor(format)
+			require.NoError(t, err)
+			assert.Equal(t, format, serializer.Format())
+
+			data, err := serializer.Marshal(created)
+			require.NoError(t, err)
+
+			var decoded events.OrderCreatedEvent
+			require.NoError(t, serializer.Unmarshal(data, &decoded))
+			assert.Equal(t, created.CustomerID, decoded.CustomerID)
+			assert.True(t, created.CreatedAt.Equal(decoded.CreatedAt))
+
+			// Whole streams are serialized for the cache
+			data, err = serializer.Marshal(stream)
+			require.NoError(t, err)
+
+			var decodedStream []events.Event
+			require.NoError(t, serializer.Unmarshal(data, &decodedStream))
+			require.Len(t, decodedStream, 1)
+			assert.Equal(t, stream[0].EventID, decodedStream[0].EventID)
+			assert.Equal(t, stream[0].Data, decodedStream[0].Data)
+			assert.Equal(t, events.FormatJSON, events.FormatOf(decodedStream[0]))
+		})
+	}
+
+	_, err := events.SerializerFor("xml")
+	assert.ErrorIs(t, err, events.ErrUnknownFormat)
+}
+
+func TestTypeRegistry_MixedFormatStream(t *testing.T) {
+
+	payload := events.OrderItemAddedEvent{ProductID: uuid.New(), Quantity: 4, UnitPrice: 2.5}
+
+	for _, format := range allFormats {
+		t.Run(string(format), func(t *testing.T) {
+
+			serializer, err := events.SerializerFor(format)
+			require.NoError(t, err)
+
+			writer := newOrderTypeRegistry().WithSerializer(serializer)
+			event, err := writer.Encode(payload)
+			require.NoError(t, err)
+			assert.Equal(t, format, events.FormatOf(event))
+
+			// A reader configured for JSON follows the recorded format
+			reader := newOrderTypeRegistry()
+			decoded, err := reader.Decode(event)
+			require.NoError(t, err)
+			assert.Equal(t, payload, decoded)
+			assert.NoError(t, reader.Validate(event))
+		})
+	}
+
+	// Strict validation rejects unknown fields in binary formats too
+	msgpack, err := events.SerializerFor(events.FormatMsgpack)
+	require.NoError(t, err)
+	data, err := msgpack.Marshal(map[string]interface{}{"Quantity": 1, "Currency": "EUR"})
+	require.NoError(t, err)
+	extra := events.NewEvent("Order", uuid.New(), events.OrderItemAddedEventType, 1, 1, data, map[string]interface{}{events.MetadataFormatKey: "msgpack"})
+	assert.ErrorIs(t, newOrderTypeRegistry().Validate(extra), events.ErrInvalidPayload)
+
+	// Events written before formats were recorded are JSON
+	legacy := events.NewEvent("Order", uuid.New(), events.OrderItemAddedEventType, 1, 1, []byte(`{"Quantity":1}`), nil)
+	assert.Equal(t, events.FormatJSON, events.FormatOf(legacy))
+	assert.NoError(t, newOrderTypeRegistry().Validate(legacy))
+}
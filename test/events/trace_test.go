@@ -0,0 +1,47 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStampTrace_FillsOnlyEmptyFields(t *testing.T) {
+	ctx := events.ContextWithTrace(context.Background(), events.Trace{
+		CorrelationID: "checkout-42",
+		CausationID:   "request-1",
+		UserID:        "user-7",
+	})
+
+	batch := []events.Event{
+		{EventID: uuid.New()},
+		{EventID: uuid.New(), CausationID: "event-9"},
+	}
+
+	events.StampTrace(ctx, batch)
+
+	assert.Equal(t, "checkout-42", batch[0].CorrelationID)
+	assert.Equal(t, "request-1", batch[0].CausationID)
+	assert.Equal(t, "user-7", batch[0].UserID)
+	assert.Empty(t, batch[0].TraceID)
+	assert.Equal(t, "event-9", batch[1].CausationID)
+}
+
+func TestTraceFromEvent_NamesEventAsCause(t *testing.T) {
+	event := events.Event{EventID: uuid.New(), CorrelationID: "checkout-42", UserID: "user-7"}
+
+	trace := events.TraceFromEvent(event)
+
+	assert.Equal(t, "checkout-42", trace.CorrelationID)
+	assert.Equal(t, event.EventID.String(), trace.CausationID)
+	assert.Equal(t, "user-7", trace.UserID)
+
+	// An event without a correlation starts one
+	event.CorrelationID = ""
+	assert.Equal(t, event.EventID.String(), events.TraceFromEvent(event).CorrelationID)
+}
@@ -0,0 +1,76 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcasterRegistry_ChainsToLatestVersion(t *testing.T) {
+
+	registry := events.NewUpcasterRegistry()
+
+	// v1 -> v2 adds a currency, v2 -> v3 renames unitPrice
+	registry.MustRegister(events.OrderItemAddedEventType, 1, events.JSONUpcaster(func(payload map[string]interface{}) error {
+		payload["Currency"] = "USD"
+		return nil
+	}))
+	registry.MustRegister(events.OrderItemAddedEventType, 2, events.JSONUpcaster(func(payload map[string]interface{}) error {
+		payload["Price"] = payload["UnitPrice"]
+		delete(payload, "UnitPrice")
+		return nil
+	}))
+
+	event := events.NewEvent("Order", uuid.New(), events.OrderItemAddedEventType, 1, 2, []byte(`{"Quantity":2,"UnitPrice":5}`), nil)
+
+	upcasted, err := registry.Upcast(event)
+	require.NoError(t, err)
+	assert.Equal(t, 3, upcasted.EventVersion)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(upcasted.Data, &payload))
+	assert.Equal(t, "USD", payload["Currency"])
+	assert.Equal(t, float64(5), payload["Price"])
+	assert.NotContains(t, payload, "UnitPrice")
+
+	// Upcasting is idempotent once the event is at its latest version
+	again, err := registry.Upcast(upcasted)
+	require.NoError(t, err)
+	assert.Equal(t, upcasted, again)
+
+	// Other event types pass through untouched
+	other := events.NewEvent("Order", uuid.New(), events.OrderSubmittedEventType, 1, 3, []byte(`{}`), nil)
+	unchanged, err := registry.Upcast(other)
+	require.NoError(t, err)
+	assert.Equal(t, other, unchanged)
+}
+
+func TestUpcasterRegistry_Errors(t *testing.T) {
+
+	registry := events.NewUpcasterRegistry()
+
+	failing := func(data []byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	}
+
+	require.NoError(t, registry.Register(events.OrderCreatedEventType, 1, failing))
+	assert.Error(t, registry.Register(events.OrderCreatedEventType, 1, failing))
+
+	event := events.NewEvent("Order", uuid.New(), events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil)
+
+	_, err := registry.Upcast(event)
+	assert.ErrorContains(t, err, "boom")
+
+	// A nil registry is a no-op
+	var none *events.UpcasterRegistry
+	unchanged, err := none.Upcast(event)
+	require.NoError(t, err)
+	assert.Equal(t, event, unchanged)
+}
@@ -0,0 +1,192 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/outbox"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/pkg/messaging"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOutboxStore implements repository.OutboxStore interface for testing
+type MockOutboxStore struct {
+	mock.Mock
+}
+
+func (m *MockOutboxStore) ClaimOutbox(ctx context.Context, owner string, lease time.Duration, limit int) ([]repository.OutboxMessage, error) {
+	args := m.Called(ctx, owner, lease, limit)
+	return args.Get(0).([]repository.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxStore) MarkPublished(ctx context.Context, owner string, id int64) error {
+	args := m.Called(ctx, owner, id)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStore) MarkFailed(ctx context.Context, owner string, id int64, retryAt time.Time, lastError string) error {
+	args := m.Called(ctx, owner, id, retryAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStore) ReleaseOutbox(ctx context.Context, owner string) error {
+	args := m.Called(ctx, owner)
+	return args.Error(0)
+}
+
+func (m *MockOutboxStore) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// failingPublisher rejects messages for one key and records the rest
+type failingPublisher struct {
+	*messaging.MemoryPublisher
+	failKey string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, msg messaging.Message) error {
+	if msg.Key == p.failKey {
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryPublisher.Publish(ctx, msg)
+}
+
+func newOutboxMessage(id int64, aggregateID uuid.UUID, sequence int64) repository.OutboxMessage {
+	event := events.NewEvent("Order", aggregateID, events.OrderItemAddedEventType, 1, sequence, []byte(`{}`), nil)
+	return repository.OutboxMessage{ID: id, Event: event}
+}
+
+func TestRelay_PublishPending(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockOutboxStore)
+	publisher := messaging.NewMemoryPublisher()
+
+	aggregateID := uuid.New()
+	messages := []repository.OutboxMessage{
+		newOutboxMessage(1, aggregateID, 1),
+		newOutboxMessage(2, aggregateID, 2),
+	}
+
+	mockStore.On("ClaimOutbox", ctx, mock.Anything, mock.Anything, mock.Anything).Return(messages, nil)
+	mockStore.On("MarkPublished", ctx, mock.Anything, int64(1)).Return(nil)
+	mockStore.On("MarkPublished", ctx, mock.Anything, int64(2)).Return(nil)
+	mockStore.On("ReleaseOutbox", ctx, mock.Anything).Return(nil)
+
+	relay, err := outbox.NewRelay(mockStore, publisher, config.DefaultOutboxConfig())
+	require.NoError(t, err)
+
+	published, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	sent := publisher.Messages()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "events", sent[0].Topic)
+	assert.Equal(t, aggregateID.String(), sent[0].Key)
+	assert.Equal(t, "1", sent[0].Headers["sequence"])
+	assert.Equal(t, "2", sent[1].Headers["sequence"])
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(sent[0].Value, &envelope))
+	assert.Equal(t, string(events.OrderItemAddedEventType), envelope["eventType"])
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestRelay_PublishPending_FailureBlocksAggregate(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockOutboxStore)
+
+	failing, healthy := uuid.New(), uuid.New()
+	publisher := &failingPublisher{MemoryPublisher: messaging.NewMemoryPublisher(), failKey: failing.String()}
+
+	messages := []repository.OutboxMessage{
+		newOutboxMessage(1, failing, 1),
+		newOutboxMessage(2, healthy, 1),
+		newOutboxMessage(3, failing, 2),
+	}
+
+	mockStore.On("ClaimOutbox", ctx, mock.Anything, mock.Anything, mock.Anything).Return(messages, nil)
+	mockStore.On("MarkFailed", ctx, mock.Anything, int64(1), mock.Anything, "broker unavailable").Return(nil)
+	mockStore.On("MarkPublished", ctx, mock.Anything, int64(2)).Return(nil)
+	mockStore.On("ReleaseOutbox", ctx, mock.Anything).Return(nil)
+
+	relay, err := outbox.NewRelay(mockStore, publisher, config.DefaultOutboxConfig())
+	require.NoError(t, err)
+
+	published, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	// The failed aggregate's later event must not overtake the failed one
+	sent := publisher.Messages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, healthy.String(), sent[0].Key)
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "MarkPublished", ctx, mock.Anything, int64(3))
+}
+
+// commitNotifier signals commits on demand
+type commitNotifier struct {
+	signals chan struct{}
+}
+
+func (n *commitNotifier) Notify(ctx context.Context) (<-chan struct{}, func() bool, error) {
+	return n.signals, func() bool { return true }, nil
+}
+
+func TestRelay_RunWakesOnCommit(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockStore := new(MockOutboxStore)
+	publisher := messaging.NewMemoryPublisher()
+
+	message := newOutboxMessage(1, uuid.New(), 1)
+
+	// The first round finds nothing; the message is only claimed after the commit signal
+	mockStore.On("ClaimOutbox", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]repository.OutboxMessage{}, nil).Once()
+	mockStore.On("ClaimOutbox", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]repository.OutboxMessage{message}, nil).Once()
+	mockStore.On("ClaimOutbox", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]repository.OutboxMessage{}, nil)
+	mockStore.On("MarkPublished", mock.Anything, mock.Anything, int64(1)).Return(nil)
+	mockStore.On("ReleaseOutbox", mock.Anything, mock.Anything).Return(nil)
+
+	cfg := config.DefaultOutboxConfig()
+	cfg.PollInterval = time.Hour
+	cfg.Retention = 0
+
+	relay, err := outbox.NewRelay(mockStore, publisher, cfg)
+	require.NoError(t, err)
+
+	notifier := &commitNotifier{signals: make(chan struct{}, 1)}
+	relay.WithNotifier(notifier)
+
+	// A commit lands while the first round is running
+	notifier.signals <- struct{}{}
+
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(publisher.Messages()) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
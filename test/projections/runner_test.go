@@ -0,0 +1,357 @@
+package projections_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/projections"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource is an in-memory event log that hands every subscriber the
+// events after its position, then waits for more
+type memorySource struct {
+	mu      sync.Mutex
+	events  []events.Event
+	changed chan struct{}
+}
+
+func newMemorySource(count int) *memorySource {
+	s := &memorySource{changed: make(chan struct{})}
+	s.append(count)
+	return s
+}
+
+func (s *memorySource) append(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < count; i++ {
+		event := events.NewEvent("Order", uuid.New(), events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil)
+		event.Position = int64(len(s.events) + 1)
+		s.events = append(s.events, event)
+	}
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *memorySource) after(position int64) ([]events.Event, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if int(position) >= len(s.events) {
+		return nil, s.changed
+	}
+	return append([]events.Event(nil), s.events[position:]...), s.changed
+}
+
+func (s *memorySource) Subscribe(ctx context.Context, fromPosition int64, handler repository.EventHandler) error {
+	return s.SubscribeBatches(ctx, fromPosition, func(batch []events.Event) error {
+		for _, event := range batch {
+			if err := handler(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *memorySource) SubscribeBatches(ctx context.Context, fromPosition int64, handler repository.BatchHandler) error {
+	for {
+		batch, changed := s.after(fromPosition)
+
+		if err := handler(batch); err != nil {
+			return err
+		}
+
+		if len(batch) > 0 {
+			fromPosition = batch[len(batch)-1].Position
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// fakeStore keeps projection checkpoints in memory. A batch only takes
+// effect on the read model if every event in it applies, as in a transaction.
+type fakeStore struct {
+	mu         sync.Mutex
+	model      *readModel
+	checkpoint int64
+	statuses   []repository.ProjectionStatus
+	resets     int
+}
+
+func (s *fakeStore) GetCheckpoint(ctx context.Context, projectionName string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoint, nil
+}
+
+func (s *fakeStore) ApplyBatch(ctx context.Context, projectionName string, fromCheckpoint int64, batch []events.Event, apply repository.ApplyFunc) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checkpoint != fromCheckpoint {
+		return 0, repository.ErrCheckpointMoved
+	}
+
+	for _, event := range batch {
+		if err := apply(ctx, nil, event); err != nil {
+			s.model.rollback()
+			return 0, err
+		}
+	}
+
+	s.model.commit()
+	s.checkpoint = batch[len(batch)-1].Position
+	return s.checkpoint, nil
+}
+
+func (s *fakeStore) UpdateProjectionStatus(ctx context.Context, projectionName string, status repository.ProjectionStatus, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses = append(s.statuses, status)
+	return nil
+}
+
+func (s *fakeStore) ResetProjection(ctx context.Context, projectionName string, reset repository.ResetFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := reset(ctx, nil); err != nil {
+		return err
+	}
+
+	s.checkpoint = 0
+	s.resets++
+	s.statuses = append(s.statuses, repository.ProjectionRebuilding)
+	return nil
+}
+
+func (s *fakeStore) state() (int64, []repository.ProjectionStatus, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoint, append([]repository.ProjectionStatus(nil), s.statuses...), s.resets
+}
+
+// readModel records the positions applied to it. failures events at failAt
+// fail before it accepts them; a negative count fails forever.
+type readModel struct {
+	mu       sync.Mutex
+	applied  []int64
+	pending  []int64
+	failAt   int64
+	failures int
+}
+
+func (m *readModel) projection() projections.Projection {
+	return projections.Projection{
+		Name: "orders",
+		Handlers: map[events.EventType]projections.Handler{
+			events.OrderCreatedEventType: m.handle,
+		},
+		Reset: func(ctx context.Context, tx repository.Tx) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.applied = nil
+			return nil
+		},
+	}
+}
+
+func (m *readModel) handle(ctx context.Context, tx repository.Tx, event events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.Position == m.failAt && m.failures != 0 {
+		m.failures--
+		return errors.New("read model unavailable")
+	}
+
+	m.pending = append(m.pending, event.Position)
+	return nil
+}
+
+func (m *readModel) commit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = append(m.applied, m.pending...)
+	m.pending = nil
+}
+
+func (m *readModel) rollback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = nil
+}
+
+func (m *readModel) positions() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.applied...)
+}
+
+func positions(from, to int64) []int64 {
+	var result []int64
+	for p := from; p <= to; p++ {
+		result = append(result, p)
+	}
+	return result
+}
+
+func TestRunner(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      int
+		checkpoint  int64
+		failAt      int64
+		failures    int
+		maxAttempts int
+		// rebuildAt requests a rebuild once the projection reports this status
+		rebuildAt repository.ProjectionStatus
+
+		wantCheckpoint int64
+		wantApplied    []int64
+		wantStatuses   []repository.ProjectionStatus
+		wantResets     int
+	}{
+		{
+			name:           "resumes from stored checkpoint",
+			events:         5,
+			checkpoint:     3,
+			maxAttempts:    3,
+			wantCheckpoint: 5,
+			wantApplied:    []int64{4, 5},
+			wantStatuses:   []repository.ProjectionStatus{repository.ProjectionRunning},
+		},
+		{
+			name:           "stalls on failure and recovers",
+			events:         4,
+			failAt:         2,
+			failures:       2,
+			maxAttempts:    3,
+			wantCheckpoint: 4,
+			wantApplied:    positions(1, 4),
+			wantStatuses: []repository.ProjectionStatus{
+				repository.ProjectionStalled,
+				repository.ProjectionStalled,
+				repository.ProjectionRunning,
+			},
+		},
+		{
+			name:           "fails after max attempts",
+			events:         4,
+			failAt:         2,
+			failures:       -1,
+			maxAttempts:    3,
+			wantCheckpoint: 0,
+			wantStatuses: []repository.ProjectionStatus{
+				repository.ProjectionStalled,
+				repository.ProjectionStalled,
+				repository.ProjectionFailed,
+			},
+		},
+		{
+			name:           "rebuild replays the log",
+			events:         3,
+			maxAttempts:    3,
+			rebuildAt:      repository.ProjectionRunning,
+			wantCheckpoint: 3,
+			wantApplied:    positions(1, 3),
+			wantStatuses: []repository.ProjectionStatus{
+				repository.ProjectionRunning,
+				repository.ProjectionRebuilding,
+				repository.ProjectionRunning,
+			},
+			wantResets: 1,
+		},
+		{
+			name:           "rebuild resumes a failed projection",
+			events:         3,
+			failAt:         1,
+			failures:       2,
+			maxAttempts:    2,
+			rebuildAt:      repository.ProjectionFailed,
+			wantCheckpoint: 3,
+			wantApplied:    positions(1, 3),
+			wantStatuses: []repository.ProjectionStatus{
+				repository.ProjectionStalled,
+				repository.ProjectionFailed,
+				repository.ProjectionRebuilding,
+				repository.ProjectionRunning,
+			},
+			wantResets: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newMemorySource(tt.events)
+			model := &readModel{failAt: tt.failAt, failures: tt.failures}
+			store := &fakeStore{model: model, checkpoint: tt.checkpoint}
+
+			runner := projections.NewRunner(source, store, projections.RunnerConfig{
+				RetryInterval:    time.Millisecond,
+				MaxRetryInterval: time.Millisecond,
+				MaxAttempts:      tt.maxAttempts,
+			})
+			require.NoError(t, runner.Register(model.projection()))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				runner.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			if tt.rebuildAt != "" {
+				require.Eventually(t, func() bool {
+					_, statuses, _ := store.state()
+					return len(statuses) > 0 && statuses[len(statuses)-1] == tt.rebuildAt
+				}, time.Second, time.Millisecond)
+
+				require.NoError(t, runner.Rebuild("orders"))
+			}
+
+			require.Eventually(t, func() bool {
+				checkpoint, statuses, resets := store.state()
+				return checkpoint == tt.wantCheckpoint && resets == tt.wantResets &&
+					len(statuses) == len(tt.wantStatuses)
+			}, time.Second, time.Millisecond)
+
+			// A failed projection stays parked, so nothing changes after it settles
+			time.Sleep(20 * time.Millisecond)
+
+			checkpoint, statuses, _ := store.state()
+			assert.Equal(t, tt.wantCheckpoint, checkpoint)
+			assert.Equal(t, tt.wantStatuses, statuses)
+			assert.Equal(t, tt.wantApplied, model.positions())
+		})
+	}
+}
+
+func TestRunner_RebuildUnknownProjection(t *testing.T) {
+	runner := projections.NewRunner(newMemorySource(0), &fakeStore{model: &readModel{}}, projections.DefaultRunnerConfig())
+
+	assert.ErrorIs(t, runner.Rebuild("missing"), repository.ErrProjectionNotFound)
+}
@@ -0,0 +1,436 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSnapshotStore implements repository.SnapshotStore interface for testing
+type MockSnapshotStore struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotStore) SaveSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, version int64, schemaVersion int, data interface{}) error {
+	args := m.Called(ctx, aggregateType, aggregateID, version, schemaVersion, data)
+	return args.Error(0)
+}
+
+func (m *MockSnapshotStore) GetSnapshot(ctx context.Context, aggregateType string, aggregateID uuid.UUID, before int64) (repository.Snapshot, error) {
+	args := m.Called(ctx, aggregateType, aggregateID, before)
+	return args.Get(0).(repository.Snapshot), args.Error(1)
+}
+
+func (m *MockSnapshotStore) GetSnapshotInfo(ctx context.Context, aggregateType string, aggregateID uuid.UUID) (repository.SnapshotInfo, error) {
+	args := m.Called(ctx, aggregateType, aggregateID)
+	return args.Get(0).(repository.SnapshotInfo), args.Error(1)
+}
+
+func (m *MockSnapshotStore) ListOutdatedSnapshots(ctx context.Context, aggregateType string, schemaVersion int, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	args := m.Called(ctx, aggregateType, schemaVersion, after, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockSnapshotStore) PruneSnapshots(ctx context.Context, aggregateType string, keep int) (int64, error) {
+	args := m.Called(ctx, aggregateType, keep)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// storedSnapshot builds a snapshot as returned by a SnapshotStore
+func storedSnapshot(version int64, schemaVersion int, data []byte) repository.Snapshot {
+	return repository.Snapshot{
+		SnapshotInfo: repository.SnapshotInfo{Version: version, SchemaVersion: schemaVersion},
+		Data:         data,
+	}
+}
+
+func newOrderRepository(store repository.EventStore, snapshots repository.SnapshotStore, policy repository.SnapshotPolicy) *repository.AggregateRepository[*order.Order] {
+	return repository.NewAggregateRepository[*order.Order](order.AggregateType, store, snapshots, order.Empty, policy)
+}
+
+func TestAggregateRepository_LoadFromSnapshotAndTail(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	// Build a history of four events and snapshot it after the second
+	source, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, source.AddItem(uuid.New(), 1, 10))
+	snapshotState, _ := json.Marshal(source)
+	require.NoError(t, source.AddItem(uuid.New(), 2, 5))
+	require.NoError(t, source.Submit())
+	history := source.UncommittedEvents()
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(0)).
+		Return(storedSnapshot(2, source.SnapshotSchemaVersion(), snapshotState), nil)
+
+	// Only the events after the snapshot are replayed
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, source.ID, repository.ReadOptions{After: 2}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			for _, event := range history[2:] {
+				require.NoError(t, handler(event))
+			}
+		}).
+		Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	loaded, err := repo.Load(ctx, source.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, loaded.Version)
+	assert.Equal(t, order.StatusSubmitted, loaded.Status)
+	assert.Equal(t, 20.0, loaded.TotalAmount)
+	assert.Len(t, loaded.Items, 2)
+
+	mockStore.AssertExpectations(t)
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestAggregateRepository_LoadNotFound(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	id := uuid.New()
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, id, int64(0)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, id, repository.ReadOptions{}, mock.Anything).Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	_, err := repo.Load(ctx, id)
+	assert.ErrorIs(t, err, repository.ErrAggregateNotFound)
+}
+
+func TestAggregateRepository_LoadAtVersionIgnoresNewerSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	source, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, source.AddItem(uuid.New(), 1, 10))
+	require.NoError(t, source.AddItem(uuid.New(), 2, 5))
+	require.NoError(t, source.Submit())
+	history := source.UncommittedEvents()
+
+	// Only snapshots up to the requested version are asked for
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(3)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, source.ID, repository.ReadOptions{Limit: 2}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			for _, event := range history[:2] {
+				require.NoError(t, handler(event))
+			}
+		}).
+		Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	loaded, err := repo.LoadAt(ctx, source.ID, repository.AtVersion(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, loaded.Version)
+	assert.Equal(t, order.StatusDraft, loaded.Status)
+	assert.Equal(t, 10.0, loaded.TotalAmount)
+
+	mockStore.AssertExpectations(t)
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestAggregateRepository_LoadAtTimeStopsAtLaterEvents(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	source, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, source.AddItem(uuid.New(), 1, 10))
+	require.NoError(t, source.Submit())
+	history := source.UncommittedEvents()
+
+	asOf := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	history[0].CreatedAt = asOf.Add(-time.Hour)
+	history[1].CreatedAt = asOf.Add(-time.Minute)
+	history[2].CreatedAt = asOf.Add(time.Minute)
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(0)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, source.ID, repository.ReadOptions{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			for _, event := range history {
+				if err := handler(event); err != nil {
+					break
+				}
+			}
+		}).
+		Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	loaded, err := repo.LoadAt(ctx, source.ID, repository.AtTime(asOf))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, loaded.Version)
+	assert.Equal(t, order.StatusDraft, loaded.Status)
+
+	// Before the order was created it did not exist
+	_, err = repo.LoadAt(ctx, source.ID, repository.AtTime(asOf.Add(-2*time.Hour)))
+	assert.ErrorIs(t, err, repository.ErrAggregateNotFound)
+}
+
+func TestAggregateRepository_SaveWithExpectedVersionAndSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, o.AddItem(uuid.New(), 1, 10))
+	changes := o.UncommittedEvents()
+
+	mockStore.On("AppendToStream", ctx, order.AggregateType, o.ID, repository.ExpectedVersionNoStream, changes).Return(int64(7), nil)
+	mockSnapshots.On("GetSnapshotInfo", ctx, order.AggregateType, o.ID).Return(repository.SnapshotInfo{}, repository.ErrSnapshotNotFound)
+	mockSnapshots.On("SaveSnapshot", ctx, order.AggregateType, o.ID, int64(2), o.SnapshotSchemaVersion(), mock.Anything).Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, repository.EveryNEvents(2))
+
+	require.NoError(t, repo.Save(ctx, o))
+	assert.Empty(t, o.UncommittedEvents())
+
+	mockStore.AssertExpectations(t)
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestSnapshotPolicies(t *testing.T) {
+
+	base := repository.SnapshotContext{
+		Version:   10,
+		Snapshot:  repository.SnapshotInfo{Version: 6},
+		StateSize: 2048,
+	}
+
+	assert.True(t, repository.EveryNEvents(4).ShouldSnapshot(base))
+	assert.False(t, repository.EveryNEvents(5).ShouldSnapshot(base))
+
+	assert.True(t, repository.StateSizeAbove(1024).ShouldSnapshot(base))
+	assert.False(t, repository.StateSizeAbove(4096).ShouldSnapshot(base))
+
+	assert.True(t, repository.AnyOf(repository.EveryNEvents(5), repository.StateSizeAbove(1024)).ShouldSnapshot(base))
+	assert.False(t, repository.NeverSnapshot().ShouldSnapshot(base))
+
+	// Nothing changed since the snapshot
+	unchanged := base
+	unchanged.Snapshot.Version = 10
+	assert.False(t, repository.StateSizeAbove(1).ShouldSnapshot(unchanged))
+	assert.False(t, repository.EveryInterval(0).ShouldSnapshot(unchanged))
+}
+
+func TestSnapshotPolicies_ReplayAndEventTypes(t *testing.T) {
+
+	base := repository.SnapshotContext{
+		Version:   10,
+		Snapshot:  repository.SnapshotInfo{Version: 8},
+		NewEvents: []events.Event{{EventType: events.OrderSubmittedEventType}},
+		Replay:    repository.ReplayStats{Events: 40, Duration: 150 * time.Millisecond},
+	}
+
+	assert.True(t, repository.ReplayLongerThan(100*time.Millisecond).ShouldSnapshot(base))
+	assert.False(t, repository.ReplayLongerThan(time.Second).ShouldSnapshot(base))
+
+	assert.True(t, repository.OnEventTypes(events.OrderSubmittedEventType).ShouldSnapshot(base))
+	assert.False(t, repository.OnEventTypes(events.OrderCancelledEventType).ShouldSnapshot(base))
+
+	policy := repository.PolicyFromConfig(config.SnapshotPolicyConfig{
+		EveryEvents:  50,
+		OnEventTypes: []string{string(events.OrderCancelledEventType)},
+	})
+	assert.False(t, policy.ShouldSnapshot(base))
+
+	base.NewEvents = append(base.NewEvents, events.Event{EventType: events.OrderCancelledEventType})
+	assert.True(t, policy.ShouldSnapshot(base))
+
+	assert.False(t, repository.PolicyFromConfig(config.SnapshotPolicyConfig{}).ShouldSnapshot(base))
+}
+
+func TestAggregateRepository_SaveSnapshotsAsynchronously(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, o.AddItem(uuid.New(), 1, 10))
+	require.NoError(t, o.Submit())
+	changes := o.UncommittedEvents()
+
+	mockStore.On("AppendToStream", ctx, order.AggregateType, o.ID, repository.ExpectedVersionNoStream, changes).Return(int64(3), nil)
+	mockSnapshots.On("GetSnapshotInfo", mock.Anything, order.AggregateType, o.ID).Return(repository.SnapshotInfo{}, repository.ErrSnapshotNotFound)
+	mockSnapshots.On("SaveSnapshot", mock.Anything, order.AggregateType, o.ID, int64(3), o.SnapshotSchemaVersion(), mock.Anything).Return(nil)
+
+	snapshotter := repository.NewAsyncSnapshotter(2, 8)
+	repo := newOrderRepository(mockStore, mockSnapshots, repository.OnEventTypes(events.OrderSubmittedEventType)).
+		WithAsyncSnapshots(snapshotter)
+
+	require.NoError(t, repo.Save(ctx, o))
+
+	// Close waits for the queued snapshot
+	require.NoError(t, snapshotter.Close(ctx))
+
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestAggregateRepository_LoadIgnoresOutdatedSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	source, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, source.AddItem(uuid.New(), 1, 10))
+	history := source.UncommittedEvents()
+
+	outdated, _ := json.Marshal(source)
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(0)).
+		Return(storedSnapshot(2, source.SnapshotSchemaVersion()-1, outdated), nil)
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(2)).
+		Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	// The whole stream is replayed instead
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, source.ID, repository.ReadOptions{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			for _, event := range history {
+				require.NoError(t, handler(event))
+			}
+		}).
+		Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	loaded, err := repo.Load(ctx, source.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, loaded.Version)
+	assert.Len(t, loaded.Items, 1)
+}
+
+func TestAggregateRepository_LoadFallsBackToOlderSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	source, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, source.AddItem(uuid.New(), 1, 10))
+	snapshotState, _ := json.Marshal(source)
+	require.NoError(t, source.Submit())
+	history := source.UncommittedEvents()
+
+	// The newest snapshot is corrupt, the one before it is intact
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(0)).
+		Return(storedSnapshot(3, source.SnapshotSchemaVersion(), []byte(`{"Items": 7`)), nil)
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, source.ID, int64(3)).
+		Return(storedSnapshot(2, source.SnapshotSchemaVersion(), snapshotState), nil)
+
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, source.ID, repository.ReadOptions{After: 2}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			require.NoError(t, handler(history[2]))
+		}).
+		Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	loaded, err := repo.Load(ctx, source.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, loaded.Version)
+	assert.Equal(t, order.StatusSubmitted, loaded.Status)
+	assert.Len(t, loaded.Items, 1)
+
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestAggregateRepository_RegenerateSnapshots(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockSnapshots := new(MockSnapshotStore)
+
+	first, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, first.AddItem(uuid.New(), 1, 10))
+	missing := uuid.New()
+
+	schemaVersion := first.SnapshotSchemaVersion()
+
+	mockSnapshots.On("ListOutdatedSnapshots", ctx, order.AggregateType, schemaVersion, uuid.Nil, 2).
+		Return([]uuid.UUID{first.ID, missing}, nil)
+	mockSnapshots.On("ListOutdatedSnapshots", ctx, order.AggregateType, schemaVersion, missing, 2).
+		Return([]uuid.UUID{}, nil)
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, mock.Anything, int64(0)).
+		Return(storedSnapshot(1, schemaVersion-1, []byte(`{}`)), nil)
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, mock.Anything, int64(1)).
+		Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, first.ID, repository.ReadOptions{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(4).(repository.EventHandler)
+			for _, event := range first.UncommittedEvents() {
+				require.NoError(t, handler(event))
+			}
+		}).
+		Return(nil)
+	mockStore.On("StreamEventsByAggregateID", ctx, order.AggregateType, missing, repository.ReadOptions{}, mock.Anything).Return(nil)
+
+	mockSnapshots.On("SaveSnapshot", ctx, order.AggregateType, first.ID, int64(2), schemaVersion, mock.Anything).Return(nil)
+
+	repo := newOrderRepository(mockStore, mockSnapshots, nil)
+
+	// The aggregate without events is skipped, the other one rewritten
+	regenerated, err := repo.RegenerateSnapshots(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, regenerated)
+
+	mockSnapshots.AssertExpectations(t)
+}
+
+// Ensure the order aggregate satisfies the repository contract
+var _ repository.Aggregate = (*order.Order)(nil)
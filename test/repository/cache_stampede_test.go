@@ -0,0 +1,213 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/pkg/telemetry"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheRequests returns the event cache counter registered by the repository
+func cacheRequests() *telemetry.Counter {
+	return telemetry.DefaultRegistry.NewCounter("espm_event_cache_requests_total", "", "aggregate_type", "result")
+}
+
+// cachedEntries encodes events as CachedEventStore list entries
+func cachedEntries(t *testing.T, stream []events.Event) [][]byte {
+	entries := make([][]byte, len(stream))
+	for i, event := range stream {
+		data, err := json.Marshal(event)
+		require.NoError(t, err)
+		entries[i] = append([]byte("json:"), data...)
+	}
+	return entries
+}
+
+func TestCachedEventStore_CoalescesConcurrentMisses(t *testing.T) {
+
+	// Loads and replays go through the same coalesced reload
+	reads := map[string]func(ctx context.Context, store *repository.CachedEventStore, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error){
+		"CoalescedOrder": func(ctx context.Context, store *repository.CachedEventStore, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error) {
+			return store.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+		},
+		"CoalescedStreamOrder": func(ctx context.Context, store *repository.CachedEventStore, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error) {
+			var result []events.Event
+			err := store.StreamEventsByAggregateID(ctx, aggregateType, aggregateID, repository.ReadOptions{}, func(event events.Event) error {
+				result = append(result, event)
+				return nil
+			})
+			return result, err
+		},
+	}
+
+	for aggregateType, read := range reads {
+		t.Run(aggregateType, func(t *testing.T) {
+
+			ctx := context.Background()
+
+			mockStore := new(MockEventStore)
+			mockCache := new(MockRedisCache)
+
+			cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+			aggregateID := uuid.New()
+			stream := []events.Event{
+				events.NewEvent(aggregateType, aggregateID, events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil),
+			}
+
+			loading := make(chan struct{})
+			release := make(chan struct{})
+
+			mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return([][]byte(nil), false, cache.ErrCacheMiss)
+			mockCache.On("FillEventList", ctx, aggregateType, aggregateID.String(), mock.Anything).Return(true, nil)
+
+			// The store blocks until every caller has missed the cache
+			mockStore.On("GetEventsByAggregateID", ctx, aggregateType, aggregateID).
+				Run(func(mock.Arguments) {
+					close(loading)
+					<-release
+				}).
+				Return(stream, nil).
+				Once()
+
+			const callers = 5
+
+			var wg sync.WaitGroup
+			results := make([][]events.Event, callers)
+
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					result, err := read(ctx, cachedStore, aggregateType, aggregateID)
+					assert.NoError(t, err)
+					results[i] = result
+				}(i)
+
+				// Let the first caller start the load before the others arrive
+				if i == 0 {
+					<-loading
+				}
+			}
+
+			// Give the other callers time to join the load in flight
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			for _, result := range results {
+				assert.Len(t, result, 1)
+			}
+
+			mockStore.AssertNumberOfCalls(t, "GetEventsByAggregateID", 1)
+			mockStore.AssertNotCalled(t, "StreamEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			assert.Equal(t, float64(1), cacheRequests().Value(aggregateType, "miss"))
+			assert.Equal(t, float64(callers-1), cacheRequests().Value(aggregateType, "coalesced"))
+		})
+	}
+}
+
+func TestCachedEventStore_ServesStaleWhileLocked(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour).
+		WithReloadLock(time.Second, time.Second)
+
+	aggregateType := "StaleOrder"
+	aggregateID := uuid.New()
+	stream := []events.Event{
+		events.NewEvent(aggregateType, aggregateID, events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil),
+	}
+
+	// The cached stream is past its TTL and another replica is reloading it
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return(cachedEntries(t, stream), false, nil)
+	mockCache.On("TryLock", ctx, "lock:stream:"+aggregateType+":"+aggregateID.String(), mock.Anything, time.Second).Return(false, nil)
+
+	result, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	require.NoError(t, err)
+	assert.Equal(t, stream[0].EventID, result[0].EventID)
+
+	mockStore.AssertNotCalled(t, "GetEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, float64(1), cacheRequests().Value(aggregateType, "stale"))
+}
+
+func TestCachedEventStore_WaitsForLockHolder(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour).
+		WithReloadLock(time.Second, time.Second)
+
+	aggregateType := "WaitingOrder"
+	aggregateID := uuid.New()
+	stream := []events.Event{
+		events.NewEvent(aggregateType, aggregateID, events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil),
+	}
+
+	// Nothing is cached until the lock holder has reloaded the stream
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return([][]byte(nil), false, cache.ErrCacheMiss).Once()
+	mockCache.On("TryLock", ctx, mock.Anything, mock.Anything, time.Second).Return(false, nil)
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return(cachedEntries(t, stream), true, nil)
+
+	result, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+
+	mockStore.AssertNotCalled(t, "GetEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, float64(1), cacheRequests().Value(aggregateType, "waited"))
+}
+
+func TestCachedEventStore_LockHolderReloads(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour).
+		WithReloadLock(time.Second, time.Second)
+
+	aggregateType := "LockedOrder"
+	aggregateID := uuid.New()
+	lockKey := "lock:stream:" + aggregateType + ":" + aggregateID.String()
+	stream := []events.Event{
+		events.NewEvent(aggregateType, aggregateID, events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil),
+	}
+
+	var token string
+
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return([][]byte(nil), false, cache.ErrCacheMiss)
+	mockCache.On("TryLock", ctx, lockKey, mock.Anything, time.Second).
+		Run(func(args mock.Arguments) { token = args.String(2) }).
+		Return(true, nil)
+	mockStore.On("GetEventsByAggregateID", ctx, aggregateType, aggregateID).Return(stream, nil)
+	mockCache.On("FillEventList", ctx, aggregateType, aggregateID.String(), mock.Anything).Return(true, nil)
+	mockCache.On("Unlock", mock.Anything, lockKey, mock.Anything).
+		Run(func(args mock.Arguments) { assert.Equal(t, token, args.String(2)) }).
+		Return(nil)
+
+	_, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	require.NoError(t, err)
+
+	mockStore.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
@@ -1,18 +1,24 @@
 package repository_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/config"
+	"github.com/HarshavardhanK/espm/internal/domain/order"
+	"github.com/HarshavardhanK/espm/internal/events"
 	"github.com/HarshavardhanK/espm/internal/repository"
 
 	"github.com/google/uuid"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockEventStore implements repository.EventStore interface for testing
@@ -20,24 +26,59 @@ type MockEventStore struct {
 	mock.Mock
 }
 
-func (m *MockEventStore) AppendEvents(ctx context.Context, events []repository.Event) error {
+func (m *MockEventStore) AppendEvents(ctx context.Context, events []events.Event) error {
 	args := m.Called(ctx, events)
 	return args.Error(0)
 }
 
-func (m *MockEventStore) GetEventsByAggregateID(ctx context.Context, aggregateType, aggregateID string) ([]repository.Event, error) {
+func (m *MockEventStore) AppendToStream(ctx context.Context, aggregateType string, aggregateID uuid.UUID, expectedVersion int64, events []events.Event) (int64, error) {
+	args := m.Called(ctx, aggregateType, aggregateID, expectedVersion, events)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEventStore) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error) {
+	args := m.Called(ctx, fromPosition, limit)
+	return args.Get(0).([]events.Event), args.Error(1)
+}
+
+func (m *MockEventStore) GetEventsByAggregateID(ctx context.Context, aggregateType string, aggregateID uuid.UUID) ([]events.Event, error) {
 	args := m.Called(ctx, aggregateType, aggregateID)
-	return args.Get(0).([]repository.Event), args.Error(1)
+	return args.Get(0).([]events.Event), args.Error(1)
 }
 
-func (m *MockEventStore) GetEventsByType(ctx context.Context, eventType string) ([]repository.Event, error) {
+func (m *MockEventStore) GetEventsByType(ctx context.Context, eventType events.EventType) ([]events.Event, error) {
 	args := m.Called(ctx, eventType)
-	return args.Get(0).([]repository.Event), args.Error(1)
+	return args.Get(0).([]events.Event), args.Error(1)
+}
+
+func (m *MockEventStore) GetEventsByCorrelationID(ctx context.Context, correlationID string) ([]events.Event, error) {
+	args := m.Called(ctx, correlationID)
+	return args.Get(0).([]events.Event), args.Error(1)
 }
 
-func (m *MockEventStore) GetEventsAfterSequence(ctx context.Context, sequence int64) ([]repository.Event, error) {
+func (m *MockEventStore) QueryEvents(ctx context.Context, query repository.EventQuery) ([]events.Event, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]events.Event), args.Error(1)
+}
+
+func (m *MockEventStore) GetEventsAfterSequence(ctx context.Context, sequence int64) ([]events.Event, error) {
 	args := m.Called(ctx, sequence)
-	return args.Get(0).([]repository.Event), args.Error(1)
+	return args.Get(0).([]events.Event), args.Error(1)
+}
+
+func (m *MockEventStore) StreamEventsByAggregateID(ctx context.Context, aggregateType string, aggregateID uuid.UUID, opts repository.ReadOptions, handler repository.EventHandler) error {
+	args := m.Called(ctx, aggregateType, aggregateID, opts, handler)
+	return args.Error(0)
+}
+
+func (m *MockEventStore) StreamEventsByType(ctx context.Context, eventType events.EventType, opts repository.ReadOptions, handler repository.EventHandler) error {
+	args := m.Called(ctx, eventType, opts, handler)
+	return args.Error(0)
+}
+
+func (m *MockEventStore) StreamEventsAfterSequence(ctx context.Context, sequence int64, opts repository.ReadOptions, handler repository.EventHandler) error {
+	args := m.Called(ctx, sequence, opts, handler)
+	return args.Error(0)
 }
 
 // MockRedisCache implements cache.RedisCache interface for testing
@@ -55,6 +96,16 @@ func (m *MockRedisCache) Set(ctx context.Context, key string, value []byte) erro
 	return args.Error(0)
 }
 
+func (m *MockRedisCache) SetVersioned(ctx context.Context, key string, version int64, value []byte) (bool, error) {
+	args := m.Called(ctx, key, version, value)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisCache) InvalidateVersioned(ctx context.Context, key string, version int64) error {
+	args := m.Called(ctx, key, version)
+	return args.Error(0)
+}
+
 func (m *MockRedisCache) GetEventStream(ctx context.Context, aggregateType, aggregateID string) ([]byte, error) {
 	args := m.Called(ctx, aggregateType, aggregateID)
 	return args.Get(0).([]byte), args.Error(1)
@@ -65,11 +116,61 @@ func (m *MockRedisCache) SetEventStream(ctx context.Context, aggregateType, aggr
 	return args.Error(0)
 }
 
+func (m *MockRedisCache) BatchSetEventStreams(ctx context.Context, streams map[string]map[string][]byte) error {
+	args := m.Called(ctx, streams)
+	return args.Error(0)
+}
+
 func (m *MockRedisCache) Delete(ctx context.Context, key string) error {
 	args := m.Called(ctx, key)
 	return args.Error(0)
 }
 
+func (m *MockRedisCache) BatchGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	args := m.Called(ctx, keys)
+	return args.Get(0).(map[string][]byte), args.Error(1)
+}
+
+func (m *MockRedisCache) BatchSet(ctx context.Context, pairs map[string][]byte) error {
+	args := m.Called(ctx, pairs)
+	return args.Error(0)
+}
+
+func (m *MockRedisCache) BatchDelete(ctx context.Context, keys []string) error {
+	args := m.Called(ctx, keys)
+	return args.Error(0)
+}
+
+func (m *MockRedisCache) GetEventList(ctx context.Context, aggregateType, aggregateID string) ([][]byte, bool, error) {
+	args := m.Called(ctx, aggregateType, aggregateID)
+	return args.Get(0).([][]byte), args.Bool(1), args.Error(2)
+}
+
+func (m *MockRedisCache) SetEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) error {
+	args := m.Called(ctx, aggregateType, aggregateID, values)
+	return args.Error(0)
+}
+
+func (m *MockRedisCache) FillEventList(ctx context.Context, aggregateType, aggregateID string, values [][]byte) (bool, error) {
+	args := m.Called(ctx, aggregateType, aggregateID, values)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisCache) AppendEventList(ctx context.Context, aggregateType, aggregateID string, expectedLen int64, values [][]byte) (bool, error) {
+	args := m.Called(ctx, aggregateType, aggregateID, expectedLen, values)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisCache) TryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, token, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisCache) Unlock(ctx context.Context, key, token string) error {
+	args := m.Called(ctx, key, token)
+	return args.Error(0)
+}
+
 func (m *MockRedisCache) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -90,13 +191,13 @@ func TestCachedEventStore_AppendEvents(t *testing.T) {
 	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
 
 	// Create test events
-	events := []repository.Event{
+	testEvents := []events.Event{
 		{
-			ID:            uuid.New().String(),
+			EventID:       uuid.New(),
 			AggregateType: "Order",
-			AggregateID:   uuid.New().String(),
-			EventType:     "OrderCreated",
-			Version:       1,
+			AggregateID:   uuid.New(),
+			EventType:     events.OrderCreatedEventType,
+			EventVersion:  1,
 			Sequence:      1,
 			Data:          []byte(`{"orderId": "123"}`),
 			Metadata:      map[string]interface{}{"source": "test"},
@@ -105,12 +206,79 @@ func TestCachedEventStore_AppendEvents(t *testing.T) {
 	}
 
 	// Set up expectations
-	mockStore.On("AppendEvents", ctx, events).Return(nil)
-	mockCache.On("Delete", ctx, mock.Anything).Return(nil)
+	mockStore.On("AppendEvents", ctx, testEvents).Return(nil)
+
+	// The new stream is cached right away
+	mockCache.On("AppendEventList", ctx, "Order", testEvents[0].AggregateID.String(), int64(0), mock.Anything).Return(true, nil)
 
 	// Test append events
-	err := cachedStore.AppendEvents(ctx, events)
+	err := cachedStore.AppendEvents(ctx, testEvents)
+	assert.NoError(t, err)
+
+	// Verify expectations
+	mockStore.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedEventStore_AppendToStream(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+	aggregateID := uuid.New()
+	testEvents := []events.Event{
+		events.NewEvent("Order", aggregateID, events.OrderItemAddedEventType, 1, 2, []byte(`{}`), nil),
+	}
+
+	// Set up expectations: the event extends a cached stream of one event
+	mockStore.On("AppendToStream", ctx, "Order", aggregateID, int64(1), testEvents).Return(int64(42), nil)
+	mockCache.On("AppendEventList", ctx, "Order", aggregateID.String(), int64(1), mock.Anything).
+		Run(func(args mock.Arguments) {
+			entries := args.Get(4).([][]byte)
+			assert.Len(t, entries, 1)
+			assert.True(t, bytes.HasPrefix(entries[0], []byte("json:")))
+		}).
+		Return(true, nil)
+
+	position, err := cachedStore.AppendToStream(ctx, "Order", aggregateID, 1, testEvents)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(42), position)
+
+	// Verify expectations
+	mockStore.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedEventStore_AppendToStream_Conflict(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+	aggregateID := uuid.New()
+	conflict := &repository.ConcurrencyConflictError{
+		AggregateType:   "Order",
+		AggregateID:     aggregateID,
+		ExpectedVersion: repository.ExpectedVersionNoStream,
+		ActualVersion:   3,
+	}
+
+	// The cache must be left untouched when the append is rejected
+	mockStore.On("AppendToStream", ctx, "Order", aggregateID, repository.ExpectedVersionNoStream, mock.Anything).Return(int64(0), conflict)
+
+	_, err := cachedStore.AppendToStream(ctx, "Order", aggregateID, repository.ExpectedVersionNoStream, nil)
+	assert.ErrorIs(t, err, repository.ErrConcurrencyConflict)
+
+	var conflictErr *repository.ConcurrencyConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, int64(3), conflictErr.ActualVersion)
 
 	// Verify expectations
 	mockStore.AssertExpectations(t)
@@ -128,17 +296,17 @@ func TestCachedEventStore_GetEventsByAggregateID_CacheHit(t *testing.T) {
 
 	// Create test data
 	aggregateType := "Order"
-	aggregateID := uuid.New().String()
+	aggregateID := uuid.New()
 
 	now := time.Now()
-	cachedEvents := []repository.Event{
+	cachedEvents := []events.Event{
 
 		{
-			ID:            uuid.New().String(),
+			EventID:       uuid.New(),
 			AggregateType: aggregateType,
 			AggregateID:   aggregateID,
-			EventType:     "OrderCreated",
-			Version:       1,
+			EventType:     events.OrderCreatedEventType,
+			EventVersion:  1,
 			Sequence:      1,
 			Data:          []byte(`{"orderId": "123"}`),
 			Metadata:      map[string]interface{}{"source": "test"},
@@ -147,26 +315,26 @@ func TestCachedEventStore_GetEventsByAggregateID_CacheHit(t *testing.T) {
 	}
 
 	// Set up expectations for cache hit
-	cachedData, _ := json.Marshal(cachedEvents)
-	mockCache.On("GetEventStream", ctx, aggregateType, aggregateID).Return(cachedData, nil)
+	cachedData, _ := json.Marshal(cachedEvents[0])
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return([][]byte{append([]byte("json:"), cachedData...)}, true, nil)
 
 	// Test get events with cache hit
-	events, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	testEvents, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
 	assert.NoError(t, err)
 
 	// Compare all fields except CreatedAt
-	assert.Equal(t, len(cachedEvents), len(events))
-
-	for i := range events {
-
-		assert.Equal(t, cachedEvents[i].ID, events[i].ID)
-		assert.Equal(t, cachedEvents[i].AggregateType, events[i].AggregateType)
-		assert.Equal(t, cachedEvents[i].AggregateID, events[i].AggregateID)
-		assert.Equal(t, cachedEvents[i].EventType, events[i].EventType)
-		assert.Equal(t, cachedEvents[i].Version, events[i].Version)
-		assert.Equal(t, cachedEvents[i].Sequence, events[i].Sequence)
-		assert.Equal(t, cachedEvents[i].Data, events[i].Data)
-		assert.Equal(t, cachedEvents[i].Metadata, events[i].Metadata)
+	assert.Equal(t, len(cachedEvents), len(testEvents))
+
+	for i := range testEvents {
+
+		assert.Equal(t, cachedEvents[i].EventID, testEvents[i].EventID)
+		assert.Equal(t, cachedEvents[i].AggregateType, testEvents[i].AggregateType)
+		assert.Equal(t, cachedEvents[i].AggregateID, testEvents[i].AggregateID)
+		assert.Equal(t, cachedEvents[i].EventType, testEvents[i].EventType)
+		assert.Equal(t, cachedEvents[i].EventVersion, testEvents[i].EventVersion)
+		assert.Equal(t, cachedEvents[i].Sequence, testEvents[i].Sequence)
+		assert.Equal(t, cachedEvents[i].Data, testEvents[i].Data)
+		assert.Equal(t, cachedEvents[i].Metadata, testEvents[i].Metadata)
 	}
 
 	// Verify expectations
@@ -185,17 +353,17 @@ func TestCachedEventStore_GetEventsByAggregateID_CacheMiss(t *testing.T) {
 
 	// Create test data
 	aggregateType := "Order"
-	aggregateID := uuid.New().String()
+	aggregateID := uuid.New()
 
 	now := time.Now()
-	storeEvents := []repository.Event{
+	storeEvents := []events.Event{
 
 		{
-			ID:            uuid.New().String(),
+			EventID:       uuid.New(),
 			AggregateType: aggregateType,
 			AggregateID:   aggregateID,
-			EventType:     "OrderCreated",
-			Version:       1,
+			EventType:     events.OrderCreatedEventType,
+			EventVersion:  1,
 			Sequence:      1,
 			Data:          []byte(`{"orderId": "123"}`),
 			Metadata:      map[string]interface{}{"source": "test"},
@@ -204,27 +372,27 @@ func TestCachedEventStore_GetEventsByAggregateID_CacheMiss(t *testing.T) {
 	}
 
 	// Set up expectations for cache miss
-	mockCache.On("GetEventStream", ctx, aggregateType, aggregateID).Return([]byte{}, cache.ErrCacheMiss)
+	mockCache.On("GetEventList", ctx, aggregateType, aggregateID.String()).Return([][]byte(nil), false, cache.ErrCacheMiss)
 	mockStore.On("GetEventsByAggregateID", ctx, aggregateType, aggregateID).Return(storeEvents, nil)
-	mockCache.On("SetEventStream", ctx, aggregateType, aggregateID, mock.Anything).Return(nil)
+	mockCache.On("FillEventList", ctx, aggregateType, aggregateID.String(), mock.Anything).Return(true, nil)
 
 	// Test get events with cache miss
-	events, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
+	testEvents, err := cachedStore.GetEventsByAggregateID(ctx, aggregateType, aggregateID)
 	assert.NoError(t, err)
 
 	// Compare all fields except CreatedAt
-	assert.Equal(t, len(storeEvents), len(events))
-
-	for i := range events {
-
-		assert.Equal(t, storeEvents[i].ID, events[i].ID)
-		assert.Equal(t, storeEvents[i].AggregateType, events[i].AggregateType)
-		assert.Equal(t, storeEvents[i].AggregateID, events[i].AggregateID)
-		assert.Equal(t, storeEvents[i].EventType, events[i].EventType)
-		assert.Equal(t, storeEvents[i].Version, events[i].Version)
-		assert.Equal(t, storeEvents[i].Sequence, events[i].Sequence)
-		assert.Equal(t, storeEvents[i].Data, events[i].Data)
-		assert.Equal(t, storeEvents[i].Metadata, events[i].Metadata)
+	assert.Equal(t, len(storeEvents), len(testEvents))
+
+	for i := range testEvents {
+
+		assert.Equal(t, storeEvents[i].EventID, testEvents[i].EventID)
+		assert.Equal(t, storeEvents[i].AggregateType, testEvents[i].AggregateType)
+		assert.Equal(t, storeEvents[i].AggregateID, testEvents[i].AggregateID)
+		assert.Equal(t, storeEvents[i].EventType, testEvents[i].EventType)
+		assert.Equal(t, storeEvents[i].EventVersion, testEvents[i].EventVersion)
+		assert.Equal(t, storeEvents[i].Sequence, testEvents[i].Sequence)
+		assert.Equal(t, storeEvents[i].Data, testEvents[i].Data)
+		assert.Equal(t, storeEvents[i].Metadata, testEvents[i].Metadata)
 	}
 
 	// Verify expectations
@@ -232,6 +400,35 @@ func TestCachedEventStore_GetEventsByAggregateID_CacheMiss(t *testing.T) {
 	mockCache.AssertExpectations(t)
 }
 
+func TestCachedEventStore_GetEventsByAggregateID_ReloadKeepsNewerCache(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+	aggregateID := uuid.New()
+	storeEvents := []events.Event{
+		events.NewEvent("Order", aggregateID, events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil),
+	}
+
+	// An append extends the cached list after the store was read, so the reload
+	// must not replace it
+	mockCache.On("GetEventList", ctx, "Order", aggregateID.String()).Return([][]byte(nil), false, cache.ErrCacheMiss)
+	mockStore.On("GetEventsByAggregateID", ctx, "Order", aggregateID).Return(storeEvents, nil)
+	mockCache.On("FillEventList", ctx, "Order", aggregateID.String(), mock.Anything).Return(false, nil)
+
+	result, err := cachedStore.GetEventsByAggregateID(ctx, "Order", aggregateID)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+
+	mockStore.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "SetEventList", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestCachedEventStore_GetEventsByType(t *testing.T) {
 
 	ctx := context.Background()
@@ -242,15 +439,15 @@ func TestCachedEventStore_GetEventsByType(t *testing.T) {
 	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
 
 	// Create test data
-	eventType := "OrderCreated"
+	eventType := events.OrderCreatedEventType
 	now := time.Now()
-	events := []repository.Event{
+	testEvents := []events.Event{
 		{
-			ID:            uuid.New().String(),
+			EventID:       uuid.New(),
 			AggregateType: "Order",
-			AggregateID:   uuid.New().String(),
+			AggregateID:   uuid.New(),
 			EventType:     eventType,
-			Version:       1,
+			EventVersion:  1,
 			Sequence:      1,
 			Data:          []byte(`{"orderId": "123"}`),
 			Metadata:      map[string]interface{}{"source": "test"},
@@ -259,24 +456,24 @@ func TestCachedEventStore_GetEventsByType(t *testing.T) {
 	}
 
 	// Set up expectations
-	mockStore.On("GetEventsByType", ctx, eventType).Return(events, nil)
+	mockStore.On("GetEventsByType", ctx, eventType).Return(testEvents, nil)
 
 	// Test get events by type
 	result, err := cachedStore.GetEventsByType(ctx, eventType)
 	assert.NoError(t, err)
 
 	// Compare all fields except CreatedAt
-	assert.Equal(t, len(events), len(result))
+	assert.Equal(t, len(testEvents), len(result))
 
 	for i := range result {
-		assert.Equal(t, events[i].ID, result[i].ID)
-		assert.Equal(t, events[i].AggregateType, result[i].AggregateType)
-		assert.Equal(t, events[i].AggregateID, result[i].AggregateID)
-		assert.Equal(t, events[i].EventType, result[i].EventType)
-		assert.Equal(t, events[i].Version, result[i].Version)
-		assert.Equal(t, events[i].Sequence, result[i].Sequence)
-		assert.Equal(t, events[i].Data, result[i].Data)
-		assert.Equal(t, events[i].Metadata, result[i].Metadata)
+		assert.Equal(t, testEvents[i].EventID, result[i].EventID)
+		assert.Equal(t, testEvents[i].AggregateType, result[i].AggregateType)
+		assert.Equal(t, testEvents[i].AggregateID, result[i].AggregateID)
+		assert.Equal(t, testEvents[i].EventType, result[i].EventType)
+		assert.Equal(t, testEvents[i].EventVersion, result[i].EventVersion)
+		assert.Equal(t, testEvents[i].Sequence, result[i].Sequence)
+		assert.Equal(t, testEvents[i].Data, result[i].Data)
+		assert.Equal(t, testEvents[i].Metadata, result[i].Metadata)
 	}
 
 	// Verify expectations
@@ -293,13 +490,13 @@ func TestCachedEventStore_GetEventsAfterSequence(t *testing.T) {
 	// Create test data
 	sequence := int64(1)
 	now := time.Now()
-	events := []repository.Event{
+	testEvents := []events.Event{
 		{
-			ID:            uuid.New().String(),
+			EventID:       uuid.New(),
 			AggregateType: "Order",
-			AggregateID:   uuid.New().String(),
-			EventType:     "OrderCreated",
-			Version:       1,
+			AggregateID:   uuid.New(),
+			EventType:     events.OrderCreatedEventType,
+			EventVersion:  1,
 			Sequence:      2,
 			Data:          []byte(`{"orderId": "123"}`),
 			Metadata:      map[string]interface{}{"source": "test"},
@@ -308,26 +505,168 @@ func TestCachedEventStore_GetEventsAfterSequence(t *testing.T) {
 	}
 
 	// Set up expectations
-	mockStore.On("GetEventsAfterSequence", ctx, sequence).Return(events, nil)
+	mockStore.On("GetEventsAfterSequence", ctx, sequence).Return(testEvents, nil)
 
 	// Test get events after sequence
 	result, err := cachedStore.GetEventsAfterSequence(ctx, sequence)
 	assert.NoError(t, err)
 
 	// Compare all fields except CreatedAt
-	assert.Equal(t, len(events), len(result))
+	assert.Equal(t, len(testEvents), len(result))
 	for i := range result {
-		assert.Equal(t, events[i].ID, result[i].ID)
-		assert.Equal(t, events[i].AggregateType, result[i].AggregateType)
-		assert.Equal(t, events[i].AggregateID, result[i].AggregateID)
-		assert.Equal(t, events[i].EventType, result[i].EventType)
-		assert.Equal(t, events[i].Version, result[i].Version)
-		assert.Equal(t, events[i].Sequence, result[i].Sequence)
-		assert.Equal(t, events[i].Data, result[i].Data)
-		assert.Equal(t, events[i].Metadata, result[i].Metadata)
+		assert.Equal(t, testEvents[i].EventID, result[i].EventID)
+		assert.Equal(t, testEvents[i].AggregateType, result[i].AggregateType)
+		assert.Equal(t, testEvents[i].AggregateID, result[i].AggregateID)
+		assert.Equal(t, testEvents[i].EventType, result[i].EventType)
+		assert.Equal(t, testEvents[i].EventVersion, result[i].EventVersion)
+		assert.Equal(t, testEvents[i].Sequence, result[i].Sequence)
+		assert.Equal(t, testEvents[i].Data, result[i].Data)
+		assert.Equal(t, testEvents[i].Metadata, result[i].Metadata)
+	}
+
+	// Verify expectations
+	mockStore.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedEventStore_ReadAll(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+	testEvents := []events.Event{
+		{EventID: uuid.New(), AggregateType: "Order", AggregateID: uuid.New(), Sequence: 1, Position: 11},
+		{EventID: uuid.New(), AggregateType: "Order", AggregateID: uuid.New(), Sequence: 1, Position: 12},
 	}
 
+	// Set up expectations
+	mockStore.On("ReadAll", ctx, int64(10), 100).Return(testEvents, nil)
+
+	result, err := cachedStore.ReadAll(ctx, 10, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, testEvents, result)
+
 	// Verify expectations
 	mockStore.AssertExpectations(t)
 	mockCache.AssertExpectations(t)
 }
+
+func TestCachedEventStore_LoadAfterSaveServedFromCache(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+	mockSnapshots := new(MockSnapshotStore)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+	repo := newOrderRepository(cachedStore, mockSnapshots, repository.NeverSnapshot())
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, o.AddItem(uuid.New(), 2, 10))
+	changes := o.UncommittedEvents()
+
+	// The save starts the cached list, which the load then reads back
+	var cached [][]byte
+
+	mockStore.On("AppendToStream", ctx, order.AggregateType, o.ID, repository.ExpectedVersionNoStream, changes).Return(int64(2), nil)
+	mockCache.On("AppendEventList", ctx, order.AggregateType, o.ID.String(), int64(0), mock.Anything).
+		Run(func(args mock.Arguments) { cached = args.Get(4).([][]byte) }).
+		Return(true, nil)
+	mockSnapshots.On("GetSnapshotInfo", ctx, order.AggregateType, o.ID).Return(repository.SnapshotInfo{}, repository.ErrSnapshotNotFound).Maybe()
+
+	require.NoError(t, repo.Save(ctx, o))
+	require.Len(t, cached, 2)
+
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, o.ID, int64(0)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+	mockCache.On("GetEventList", ctx, order.AggregateType, o.ID.String()).Return(cached, true, nil)
+
+	loaded, err := repo.Load(ctx, o.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), loaded.AggregateVersion())
+	assert.Len(t, loaded.Items, 1)
+
+	mockStore.AssertNotCalled(t, "StreamEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "GetEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedEventStore_StreamEventsByAggregateIDHonoursOptions(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedEventStore(mockStore, mockCache, time.Hour)
+
+	aggregateID := uuid.New()
+	stream := make([]events.Event, 5)
+	for i := range stream {
+		stream[i] = events.NewEvent("Order", aggregateID, events.OrderItemAddedEventType, 1, int64(i+1), []byte(`{}`), nil)
+	}
+
+	mockCache.On("GetEventList", ctx, "Order", aggregateID.String()).Return(cachedEntries(t, stream), true, nil)
+
+	var sequences []int64
+	err := cachedStore.StreamEventsByAggregateID(ctx, "Order", aggregateID, repository.ReadOptions{After: 2, Limit: 2}, func(event events.Event) error {
+		sequences = append(sequences, event.Sequence)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, sequences)
+
+	mockStore.AssertNotCalled(t, "StreamEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// quietBus is an invalidation bus without other replicas
+type quietBus struct{}
+
+func (quietBus) Publish(ctx context.Context, keys []string) error {
+	return nil
+}
+
+func (quietBus) Subscribe(ctx context.Context, onKeys func(keys []string), onReset func()) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestCachedEventStore_RepeatedLoadServedFromLocalTier(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockStore := new(MockEventStore)
+	mockRemote := new(MockRedisCache)
+	mockSnapshots := new(MockSnapshotStore)
+
+	tiered := cache.NewTieredCache(mockRemote, quietBus{}, config.DefaultRedisConfig())
+	mockRemote.On("Close").Return(nil)
+	defer tiered.Close()
+
+	repo := newOrderRepository(repository.NewCachedEventStore(mockStore, tiered, time.Hour), mockSnapshots, nil)
+
+	o, err := order.NewOrder(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, o.AddItem(uuid.New(), 2, 10))
+
+	// Redis is only asked once; the second load finds the list in process memory
+	mockRemote.On("GetEventList", ctx, order.AggregateType, o.ID.String()).
+		Return(cachedEntries(t, o.UncommittedEvents()), true, nil).
+		Once()
+	mockSnapshots.On("GetSnapshot", ctx, order.AggregateType, o.ID, int64(0)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	for i := 0; i < 2; i++ {
+		loaded, err := repo.Load(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), loaded.AggregateVersion())
+	}
+
+	mockRemote.AssertNumberOfCalls(t, "GetEventList", 1)
+	mockStore.AssertNotCalled(t, "StreamEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "GetEventsByAggregateID", mock.Anything, mock.Anything, mock.Anything)
+}
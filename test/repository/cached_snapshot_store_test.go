@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/cache"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedSnapshotStore_GetSnapshotMissFillsCache(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockSnapshots := new(MockSnapshotStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedSnapshotStore(mockSnapshots, mockCache)
+
+	id := uuid.New()
+	key := "snapshots:Order:" + id.String()
+	stored := storedSnapshot(4, 1, []byte(`{"Version":4}`))
+
+	mockCache.On("Get", ctx, key).Return([]byte(nil), cache.ErrCacheMiss)
+	mockSnapshots.On("GetSnapshot", ctx, "Order", id, int64(0)).Return(stored, nil)
+	mockCache.On("SetVersioned", ctx, key, int64(4), mock.Anything).Return(true, nil)
+
+	snapshot, err := cachedStore.GetSnapshot(ctx, "Order", id, 0)
+	require.NoError(t, err)
+	assert.Equal(t, stored, snapshot)
+
+	mockSnapshots.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedSnapshotStore_GetSnapshotDropsSupersededWriteBack(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockSnapshots := new(MockSnapshotStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedSnapshotStore(mockSnapshots, mockCache)
+
+	id := uuid.New()
+	key := "snapshots:Order:" + id.String()
+	stored := storedSnapshot(4, 1, []byte(`{"Version":4}`))
+
+	// Version 5 is saved, and the key invalidated, between the read and the
+	// write back, so the cache refuses version 4
+	mockCache.On("Get", ctx, key).Return([]byte(nil), cache.ErrCacheMiss)
+	mockSnapshots.On("GetSnapshot", ctx, "Order", id, int64(0)).Return(stored, nil)
+	mockCache.On("SetVersioned", ctx, key, int64(4), mock.Anything).Return(false, nil)
+
+	snapshot, err := cachedStore.GetSnapshot(ctx, "Order", id, 0)
+	require.NoError(t, err)
+	assert.Equal(t, stored, snapshot)
+
+	mockSnapshots.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockSnapshots.AssertNotCalled(t, "GetSnapshotInfo", ctx, "Order", id)
+}
+
+func TestCachedSnapshotStore_GetSnapshotDropsUndecodableEntry(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockSnapshots := new(MockSnapshotStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedSnapshotStore(mockSnapshots, mockCache)
+
+	id := uuid.New()
+	key := "snapshots:Order:" + id.String()
+	stored := storedSnapshot(4, 1, []byte(`{"Version":4}`))
+
+	mockCache.On("Get", ctx, key).Return([]byte("not json"), nil)
+	mockCache.On("Delete", ctx, key).Return(nil)
+	mockSnapshots.On("GetSnapshot", ctx, "Order", id, int64(0)).Return(stored, nil)
+	mockCache.On("SetVersioned", ctx, key, int64(4), mock.Anything).Return(true, nil)
+
+	snapshot, err := cachedStore.GetSnapshot(ctx, "Order", id, 0)
+	require.NoError(t, err)
+	assert.Equal(t, stored, snapshot)
+
+	mockSnapshots.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestCachedSnapshotStore_GetSnapshotHit(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockSnapshots := new(MockSnapshotStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedSnapshotStore(mockSnapshots, mockCache)
+
+	id := uuid.New()
+	stored := storedSnapshot(4, 1, []byte(`{"Version":4}`))
+	data, _ := json.Marshal(stored)
+
+	mockCache.On("Get", ctx, "snapshots:Order:"+id.String()).Return(data, nil)
+
+	snapshot, err := cachedStore.GetSnapshot(ctx, "Order", id, 0)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Data, snapshot.Data)
+	assert.Equal(t, int64(4), snapshot.Version)
+
+	info, err := cachedStore.GetSnapshotInfo(ctx, "Order", id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.SchemaVersion)
+
+	// Older snapshots are never cached
+	mockSnapshots.On("GetSnapshot", ctx, "Order", id, int64(4)).Return(repository.Snapshot{}, repository.ErrSnapshotNotFound)
+
+	_, err = cachedStore.GetSnapshot(ctx, "Order", id, 4)
+	assert.ErrorIs(t, err, repository.ErrSnapshotNotFound)
+
+	mockSnapshots.AssertExpectations(t)
+}
+
+func TestCachedSnapshotStore_SaveInvalidates(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockSnapshots := new(MockSnapshotStore)
+	mockCache := new(MockRedisCache)
+
+	cachedStore := repository.NewCachedSnapshotStore(mockSnapshots, mockCache)
+
+	id := uuid.New()
+
+	mockSnapshots.On("SaveSnapshot", ctx, "Order", id, int64(5), 1, mock.Anything).Return(nil)
+	mockCache.On("InvalidateVersioned", ctx, "snapshots:Order:"+id.String(), int64(5)).Return(nil)
+
+	require.NoError(t, cachedStore.SaveSnapshot(ctx, "Order", id, 5, 1, map[string]int{"Version": 5}))
+
+	mockSnapshots.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
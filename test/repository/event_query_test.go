@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventQuery_Builder(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	base := repository.NewEventQuery().WithEventTypes(events.OrderCancelledEventType)
+	query := base.
+		WithEventTypes(events.OrderSubmittedEventType).
+		WithAggregateTypes("Order").
+		CreatedBetween(from, to).
+		WithMetadata("channel", "web").
+		OrderDescending().
+		WithLimit(50)
+
+	assert.Equal(t, []events.EventType{events.OrderCancelledEventType, events.OrderSubmittedEventType}, query.EventTypes)
+	assert.Equal(t, []string{"Order"}, query.AggregateTypes)
+	assert.Equal(t, map[string]interface{}{"channel": "web"}, query.Metadata)
+	assert.Equal(t, from, query.From)
+	assert.Equal(t, to, query.To)
+	assert.True(t, query.Descending)
+	assert.Equal(t, 50, query.Limit)
+	assert.NoError(t, query.Validate())
+
+	// Chaining copies, so a shared base query is never modified
+	assert.Equal(t, []events.EventType{events.OrderCancelledEventType}, base.EventTypes)
+	assert.Nil(t, base.Metadata)
+}
+
+func TestEventQuery_ValidateRejectsInvertedRange(t *testing.T) {
+	now := time.Now()
+
+	err := repository.NewEventQuery().CreatedBetween(now, now.Add(-time.Hour)).Validate()
+
+	assert.True(t, errors.Is(err, repository.ErrInvalidEventQuery))
+}
@@ -0,0 +1,158 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// itemAddedV2 is a v2 schema whose upcaster renames Sku to ProductID
+type itemAddedV2 struct {
+	ProductID string
+	Quantity  int
+}
+
+// memoryPayloadStore keeps history in memory and can fail one rewrite
+type memoryPayloadStore struct {
+	history  []events.Event
+	failOn   uuid.UUID
+	rewrites int
+}
+
+func (s *memoryPayloadStore) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error) {
+	var batch []events.Event
+	for _, event := range s.history {
+		if event.Position > fromPosition && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+func (s *memoryPayloadStore) RewriteEventPayload(ctx context.Context, eventID uuid.UUID, eventVersion int, data []byte) error {
+	if eventID == s.failOn {
+		return errors.New("connection reset")
+	}
+
+	for i := range s.history {
+		if s.history[i].EventID == eventID {
+			s.history[i].EventVersion = eventVersion
+			s.history[i].Data = data
+			s.rewrites++
+			return nil
+		}
+	}
+	return errors.New("event not found")
+}
+
+func newPayloadMigrationFixture(t *testing.T) (*memoryPayloadStore, repository.PayloadMigration) {
+	t.Helper()
+
+	upcasters := events.NewUpcasterRegistry()
+	upcasters.MustRegister(events.OrderItemAddedEventType, 1, events.JSONUpcaster(func(payload map[string]interface{}) error {
+		payload["ProductID"] = payload["Sku"]
+		delete(payload, "Sku")
+		return nil
+	}))
+
+	eventTypes := events.NewTypeRegistry()
+	eventTypes.MustRegister(events.OrderItemAddedEventType, 2, itemAddedV2{})
+
+	aggregateID := uuid.New()
+	payloads := []struct {
+		version int
+		data    string
+	}{
+		{1, `{"Sku":"a","Quantity":1}`},
+		{2, `{"ProductID":"b","Quantity":2}`},
+		{1, `{"Sku":"c","Quantity":3,"Colour":"red"}`},
+		{1, `{"Sku":"d","Quantity":4}`},
+		{1, `{"Sku":"e","Quantity":5}`},
+	}
+
+	store := &memoryPayloadStore{}
+	for i, payload := range payloads {
+		event := events.NewEvent("Order", aggregateID, events.OrderItemAddedEventType, payload.version, int64(i+1), []byte(payload.data), nil)
+		event.Position = int64(i + 1)
+		store.history = append(store.history, event)
+	}
+
+	return store, repository.PayloadMigration{
+		Store:      store,
+		Upcasters:  upcasters,
+		EventTypes: eventTypes,
+		BatchSize:  2,
+	}
+}
+
+func TestPayloadMigration_VerifyReportsWithoutWriting(t *testing.T) {
+	store, migration := newPayloadMigrationFixture(t)
+
+	report, err := migration.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.Scanned)
+	assert.Equal(t, 3, report.Outdated)
+	assert.Equal(t, 0, report.Rewritten)
+	assert.Equal(t, int64(5), report.LastPosition)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, store.history[2].EventID, report.Failures[0].Event.EventID)
+	assert.ErrorIs(t, report.Failures[0].Err, events.ErrInvalidPayload)
+	assert.Zero(t, store.rewrites)
+}
+
+func TestPayloadMigration_RewriteStoresUpcastPayloads(t *testing.T) {
+	store, migration := newPayloadMigrationFixture(t)
+	migration.Rewrite = true
+
+	report, err := migration.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Rewritten)
+
+	for _, index := range []int{0, 3, 4} {
+		event := store.history[index]
+		assert.Equal(t, 2, event.EventVersion)
+		var payload itemAddedV2
+		require.NoError(t, json.Unmarshal(event.Data, &payload))
+		assert.NotEmpty(t, payload.ProductID)
+	}
+
+	// The invalid event is left as it was
+	assert.Equal(t, 1, store.history[2].EventVersion)
+
+	// A second run finds nothing left to rewrite
+	again, err := migration.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, again.Outdated)
+	assert.Len(t, again.Failures, 1)
+}
+
+func TestPayloadMigration_StoppedRewriteResumesFromLastPosition(t *testing.T) {
+	store, migration := newPayloadMigrationFixture(t)
+	migration.Rewrite = true
+	store.failOn = store.history[3].EventID
+
+	report, err := migration.Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int64(3), report.LastPosition)
+	assert.Equal(t, 1, report.Rewritten)
+
+	// Continue from the reported position once the store recovers
+	store.failOn = uuid.Nil
+	migration.From = report.LastPosition
+
+	resumed, err := migration.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, resumed.Scanned)
+	assert.Equal(t, 2, resumed.Rewritten)
+	assert.Equal(t, 3, store.rewrites)
+}
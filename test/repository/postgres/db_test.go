@@ -0,0 +1,71 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/lib/pq"
+)
+
+// testDatabaseEnv names the PostgreSQL URL the store tests run against.
+// The tests are skipped when it is unset.
+const testDatabaseEnv = "ESPM_TEST_DATABASE_URL"
+
+// migrationsDir holds the schema migrations applied to each test database
+const migrationsDir = "../../../deploy/docker/migrations"
+
+// openTestDB returns a connection to a fresh schema with every up migration
+// applied, and the URL other connections to it can use. The schema is dropped
+// when the test ends.
+func openTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+
+	base := os.Getenv(testDatabaseEnv)
+	if base == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	admin, err := sql.Open("postgres", base)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	_, err = admin.Exec(`CREATE SCHEMA ` + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	u, err := url.Parse(base)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	connStr := u.String()
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
+	require.NoError(t, err)
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = db.Exec(string(migration))
+		require.NoError(t, err, fmt.Sprintf("migration %s", filepath.Base(file)))
+	}
+
+	return db, connStr
+}
@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventIDs returns the IDs of events in order
+func eventIDs(stream []events.Event) []uuid.UUID {
+	ids := make([]uuid.UUID, len(stream))
+	for i, event := range stream {
+		ids[i] = event.EventID
+	}
+	return ids
+}
+
+func TestPostgresEventStore_QueryEventsFilters(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	at := func(aggregateID uuid.UUID, eventType events.EventType, sequence int64, hours int, channel string) events.Event {
+		event := events.NewEvent("Order", aggregateID, eventType, 1, sequence, []byte(`{}`), map[string]interface{}{"channel": channel})
+		event.CreatedAt = start.Add(time.Duration(hours) * time.Hour)
+		return event
+	}
+
+	first, second := uuid.New(), uuid.New()
+	stored := []events.Event{
+		at(first, events.OrderCreatedEventType, 1, 0, "web"),
+		at(first, events.OrderSubmittedEventType, 2, 1, "web"),
+		at(second, events.OrderCreatedEventType, 1, 2, "app"),
+		at(second, events.OrderCancelledEventType, 2, 3, "app"),
+		at(first, events.OrderCancelledEventType, 3, 4, "web"),
+	}
+	require.NoError(t, store.AppendEvents(ctx, stored))
+
+	tests := []struct {
+		name  string
+		query repository.EventQuery
+		want  []events.Event
+	}{
+		{
+			name:  "any of several types",
+			query: repository.NewEventQuery().WithEventTypes(events.OrderSubmittedEventType, events.OrderCancelledEventType),
+			want:  []events.Event{stored[1], stored[3], stored[4]},
+		},
+		{
+			name:  "time range includes from and excludes to",
+			query: repository.NewEventQuery().CreatedBetween(start.Add(time.Hour), start.Add(3*time.Hour)),
+			want:  []events.Event{stored[1], stored[2]},
+		},
+		{
+			name:  "open ended range",
+			query: repository.NewEventQuery().CreatedBetween(start.Add(3*time.Hour), time.Time{}),
+			want:  []events.Event{stored[3], stored[4]},
+		},
+		{
+			name: "types within a range of one stream",
+			query: repository.NewEventQuery().
+				WithAggregateTypes("Order").
+				WithAggregateIDs(first).
+				WithEventTypes(events.OrderCreatedEventType, events.OrderCancelledEventType).
+				CreatedBetween(start, start.Add(5*time.Hour)),
+			want: []events.Event{stored[0], stored[4]},
+		},
+		{
+			name:  "metadata",
+			query: repository.NewEventQuery().WithMetadata("channel", "app"),
+			want:  []events.Event{stored[2], stored[3]},
+		},
+		{
+			name:  "newest first with a limit",
+			query: repository.NewEventQuery().OrderDescending().WithLimit(2),
+			want:  []events.Event{stored[4], stored[3]},
+		},
+		{
+			name:  "next descending page",
+			query: repository.NewEventQuery().OrderDescending().WithLimit(2).StartAfter(stored[3].Position),
+			want:  []events.Event{stored[2], stored[1]},
+		},
+		{
+			name:  "next ascending page",
+			query: repository.NewEventQuery().WithLimit(2).StartAfter(stored[1].Position),
+			want:  []events.Event{stored[2], stored[3]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.QueryEvents(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, eventIDs(tt.want), eventIDs(result))
+		})
+	}
+
+	_, err := store.QueryEvents(ctx, repository.NewEventQuery().CreatedBetween(start, start))
+	assert.ErrorIs(t, err, repository.ErrInvalidEventQuery)
+}
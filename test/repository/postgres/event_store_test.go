@@ -0,0 +1,264 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orderEvent(aggregateID uuid.UUID, sequence int64) events.Event {
+	return events.NewEvent("Order", aggregateID, events.OrderCreatedEventType, 1, sequence, []byte(`{}`), nil)
+}
+
+func TestPostgresEventStore_ConcurrentWritersNeverShareSequence(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	for round := 0; round < 20; round++ {
+		aggregateID := uuid.New()
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, errs[0] = store.AppendToStream(ctx, "Order", aggregateID, repository.ExpectedVersionNoStream,
+				[]events.Event{orderEvent(aggregateID, 0)})
+		}()
+		go func() {
+			defer wg.Done()
+			errs[1] = store.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 1)})
+		}()
+		wg.Wait()
+
+		// Exactly one writer gets version 1, the other sees a conflict
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+			} else {
+				assert.ErrorIs(t, err, repository.ErrConcurrencyConflict)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+
+		stored, err := store.GetEventsByAggregateID(ctx, "Order", aggregateID)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, int64(1), stored[0].Sequence)
+	}
+}
+
+func TestPostgresEventStore_AppendEventsRejectsTakenSequence(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	require.NoError(t, store.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 1), orderEvent(aggregateID, 2)}))
+
+	err := store.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 2)})
+
+	var conflict *repository.ConcurrencyConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, int64(1), conflict.ExpectedVersion)
+	assert.Equal(t, int64(2), conflict.ActualVersion)
+}
+
+func TestPostgresEventStore_PartialRetryLeavesPositionsUnset(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	first := orderEvent(aggregateID, 1)
+	require.NoError(t, store.AppendEvents(ctx, []events.Event{first}))
+
+	// The first event was stored before, the second reuses an ID in another stream
+	stored := first
+	stored.Position = 0
+	clash := orderEvent(uuid.New(), 1)
+	clash.EventID = first.EventID
+	batch := []events.Event{stored, clash}
+
+	err := store.AppendEvents(ctx, batch)
+	assert.ErrorIs(t, err, repository.ErrDuplicateEventID)
+
+	for _, event := range batch {
+		assert.Zero(t, event.Position)
+	}
+}
+
+func TestPostgresEventStore_ReadAllFollowsCommitOrder(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	first, second := uuid.New(), uuid.New()
+
+	// Commits alternate between two streams
+	var committed []events.Event
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		batch := []events.Event{orderEvent(first, sequence)}
+		require.NoError(t, store.AppendEvents(ctx, batch))
+		committed = append(committed, batch...)
+
+		appended := []events.Event{orderEvent(second, 0)}
+		_, err := store.AppendToStream(ctx, "Order", second, sequence-1, appended)
+		require.NoError(t, err)
+		committed = append(committed, appended...)
+	}
+
+	all, err := store.ReadAll(ctx, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, all, len(committed))
+
+	for i, event := range all {
+		assert.Equal(t, committed[i].EventID, event.EventID)
+		assert.Equal(t, committed[i].Position, event.Position)
+		if i > 0 {
+			assert.Greater(t, event.Position, all[i-1].Position)
+		}
+	}
+
+	// Reading resumes past a position and stops at the limit
+	page, err := store.ReadAll(ctx, all[1].Position, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, all[2].EventID, page[0].EventID)
+	assert.Equal(t, all[3].EventID, page[1].EventID)
+
+	page, err = store.ReadAll(ctx, all[len(all)-1].Position, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+// collect returns a handler that records the events it receives
+func collect(into *[]events.Event) repository.EventHandler {
+	return func(event events.Event) error {
+		*into = append(*into, event)
+		return nil
+	}
+}
+
+func TestPostgresEventStore_StreamEventsByAggregateIDPages(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	batch := make([]events.Event, 5)
+	for i := range batch {
+		batch[i] = orderEvent(aggregateID, int64(i+1))
+	}
+	require.NoError(t, store.AppendEvents(ctx, batch))
+	require.NoError(t, store.AppendEvents(ctx, []events.Event{orderEvent(uuid.New(), 1)}))
+
+	var all []events.Event
+	require.NoError(t, store.StreamEventsByAggregateID(ctx, "Order", aggregateID, repository.ReadOptions{BatchSize: 2}, collect(&all)))
+	require.Len(t, all, 5)
+	for i, event := range all {
+		assert.Equal(t, int64(i+1), event.Sequence)
+	}
+
+	// Resuming past a sequence number with a limit that ends mid-page
+	var resumed []events.Event
+	opts := repository.ReadOptions{BatchSize: 2, After: 1, Limit: 3}
+	require.NoError(t, store.StreamEventsByAggregateID(ctx, "Order", aggregateID, opts, collect(&resumed)))
+	require.Len(t, resumed, 3)
+	assert.Equal(t, []int64{2, 3, 4}, []int64{resumed[0].Sequence, resumed[1].Sequence, resumed[2].Sequence})
+
+	// A handler error stops the read
+	stop := errors.New("stop")
+	delivered := 0
+	err := store.StreamEventsByAggregateID(ctx, "Order", aggregateID, repository.ReadOptions{BatchSize: 2}, func(events.Event) error {
+		delivered++
+		if delivered == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 3, delivered)
+}
+
+func TestPostgresEventStore_StreamEventsByTypeResumesAfterPosition(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	var created []events.Event
+	for i := 0; i < 4; i++ {
+		aggregateID := uuid.New()
+		batch := []events.Event{
+			orderEvent(aggregateID, 1),
+			events.NewEvent("Order", aggregateID, events.OrderSubmittedEventType, 1, 2, []byte(`{}`), nil),
+		}
+		require.NoError(t, store.AppendEvents(ctx, batch))
+		created = append(created, batch[0])
+	}
+
+	var all []events.Event
+	require.NoError(t, store.StreamEventsByType(ctx, events.OrderCreatedEventType, repository.ReadOptions{BatchSize: 3}, collect(&all)))
+	require.Len(t, all, 4)
+	for i, event := range all {
+		assert.Equal(t, created[i].EventID, event.EventID)
+		assert.Equal(t, events.OrderCreatedEventType, event.EventType)
+	}
+
+	var resumed []events.Event
+	opts := repository.ReadOptions{BatchSize: 1, After: all[1].Position}
+	require.NoError(t, store.StreamEventsByType(ctx, events.OrderCreatedEventType, opts, collect(&resumed)))
+	require.Len(t, resumed, 2)
+	assert.Equal(t, all[2].EventID, resumed[0].EventID)
+	assert.Equal(t, all[3].EventID, resumed[1].EventID)
+}
+
+func TestPostgresEventStore_StreamEventsAfterSequenceAcrossAggregates(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	// Three streams share each sequence number
+	for i := 0; i < 3; i++ {
+		aggregateID := uuid.New()
+		require.NoError(t, store.AppendEvents(ctx, []events.Event{
+			orderEvent(aggregateID, 1),
+			orderEvent(aggregateID, 2),
+			orderEvent(aggregateID, 3),
+		}))
+	}
+
+	// Pages of one event must not skip events sharing a sequence number
+	var streamed []events.Event
+	require.NoError(t, store.StreamEventsAfterSequence(ctx, 1, repository.ReadOptions{BatchSize: 1}, collect(&streamed)))
+	require.Len(t, streamed, 6)
+
+	seen := make(map[uuid.UUID]bool)
+	for i, event := range streamed {
+		assert.False(t, seen[event.EventID])
+		seen[event.EventID] = true
+		assert.Greater(t, event.Sequence, int64(1))
+		if i > 0 {
+			previous := streamed[i-1]
+			assert.True(t, previous.Sequence < event.Sequence ||
+				(previous.Sequence == event.Sequence && previous.Position < event.Position))
+		}
+	}
+
+	var limited []events.Event
+	require.NoError(t, store.StreamEventsAfterSequence(ctx, 0, repository.ReadOptions{BatchSize: 2, Limit: 4}, collect(&limited)))
+	assert.Len(t, limited, 4)
+}
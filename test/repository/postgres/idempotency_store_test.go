@@ -0,0 +1,63 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresIdempotencyStore_CompleteAndReplay(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresIdempotencyStore(db)
+	ctx := context.Background()
+
+	record, reserved, err := store.Reserve(ctx, "key-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NotEmpty(t, record.Token)
+
+	require.NoError(t, store.Complete(ctx, "key-1", record.Token, 201, "application/json", []byte(`{"id":1}`)))
+
+	replay, reserved, err := store.Reserve(ctx, "key-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.True(t, replay.Completed)
+	assert.Equal(t, 201, replay.StatusCode)
+	assert.Equal(t, []byte(`{"id":1}`), replay.Response)
+}
+
+func TestPostgresIdempotencyStore_StaleReservationCannotCompleteTakeover(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresIdempotencyStore(db)
+	ctx := context.Background()
+
+	stale, reserved, err := store.Reserve(ctx, "key-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// The first request hangs until its reservation goes stale and a retry takes over
+	_, err = db.Exec(`UPDATE idempotency_keys SET created_at = created_at - INTERVAL '1 hour'`)
+	require.NoError(t, err)
+
+	takeover, reserved, err := store.Reserve(ctx, "key-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NotEqual(t, stale.Token, takeover.Token)
+
+	err = store.Complete(ctx, "key-1", stale.Token, 500, "application/json", nil)
+	assert.ErrorIs(t, err, repository.ErrReservationLost)
+	require.NoError(t, store.Release(ctx, "key-1", stale.Token))
+
+	require.NoError(t, store.Complete(ctx, "key-1", takeover.Token, 201, "application/json", []byte(`{}`)))
+
+	replay, reserved, err := store.Reserve(ctx, "key-1", "hash-1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, 201, replay.StatusCode)
+}
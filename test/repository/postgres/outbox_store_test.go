@@ -0,0 +1,115 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outboxIDs returns the event IDs of outbox messages in order
+func outboxIDs(messages []repository.OutboxMessage) []uuid.UUID {
+	ids := make([]uuid.UUID, len(messages))
+	for i, message := range messages {
+		ids[i] = message.Event.EventID
+	}
+	return ids
+}
+
+func TestPostgresOutboxStore_ClaimLeasesWholeAggregates(t *testing.T) {
+	db, _ := openTestDB(t)
+	eventStore := postgres.NewPostgresEventStore(db)
+	outbox := postgres.NewPostgresOutboxStore(db)
+	ctx := context.Background()
+
+	first, second := uuid.New(), uuid.New()
+	firstEvents := []events.Event{orderEvent(first, 1), orderEvent(first, 2)}
+	secondEvents := []events.Event{orderEvent(second, 1)}
+	require.NoError(t, eventStore.AppendEvents(ctx, firstEvents))
+	require.NoError(t, eventStore.AppendEvents(ctx, secondEvents))
+
+	// A limit of one aggregate still hands over all of its pending messages
+	claimed, err := outbox.ClaimOutbox(ctx, "publisher-1", time.Minute, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{firstEvents[0].EventID, firstEvents[1].EventID}, outboxIDs(claimed))
+
+	// The leased aggregate is skipped by other publishers
+	other, err := outbox.ClaimOutbox(ctx, "publisher-2", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{secondEvents[0].EventID}, outboxIDs(other))
+
+	assert.ErrorIs(t, outbox.MarkPublished(ctx, "publisher-2", claimed[0].ID), repository.ErrLeaseLost)
+
+	for _, message := range claimed {
+		require.NoError(t, outbox.MarkPublished(ctx, "publisher-1", message.ID))
+	}
+
+	// Published messages are never claimed again, and are purged once old enough
+	require.NoError(t, outbox.ReleaseOutbox(ctx, "publisher-2"))
+	again, err := outbox.ClaimOutbox(ctx, "publisher-1", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{secondEvents[0].EventID}, outboxIDs(again))
+
+	purged, err := outbox.PurgePublished(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestPostgresOutboxStore_FailedMessageHoldsBackItsAggregate(t *testing.T) {
+	db, _ := openTestDB(t)
+	eventStore := postgres.NewPostgresEventStore(db)
+	outbox := postgres.NewPostgresOutboxStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	require.NoError(t, eventStore.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 1), orderEvent(aggregateID, 2)}))
+
+	claimed, err := outbox.ClaimOutbox(ctx, "publisher-1", time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	// The head failed, so the later message must wait for its retry
+	require.NoError(t, outbox.MarkFailed(ctx, "publisher-1", claimed[0].ID, time.Now().Add(time.Hour), "broker down"))
+
+	waiting, err := outbox.ClaimOutbox(ctx, "publisher-2", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, waiting)
+
+	assert.ErrorIs(t, outbox.MarkFailed(ctx, "publisher-1", claimed[0].ID, time.Now(), "late"), repository.ErrLeaseLost)
+
+	// Once due, the aggregate is claimable again with the attempt counted
+	_, err = db.Exec(`UPDATE outbox SET next_attempt_at = NOW() - INTERVAL '1 second'`)
+	require.NoError(t, err)
+
+	retried, err := outbox.ClaimOutbox(ctx, "publisher-2", time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, retried, 2)
+	assert.Equal(t, claimed[0].Event.EventID, retried[0].Event.EventID)
+	assert.Equal(t, 1, retried[0].Attempts)
+	assert.Equal(t, 0, retried[1].Attempts)
+}
+
+func TestPostgresOutboxStore_RejectedAppendWritesNoMessages(t *testing.T) {
+	db, _ := openTestDB(t)
+	eventStore := postgres.NewPostgresEventStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	require.NoError(t, eventStore.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 1)}))
+
+	// The conflicting append rolls back together with its outbox rows
+	err := eventStore.AppendEvents(ctx, []events.Event{orderEvent(aggregateID, 1)})
+	require.ErrorIs(t, err, repository.ErrConcurrencyConflict)
+
+	var messages int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM outbox`).Scan(&messages))
+	assert.Equal(t, 1, messages)
+}
@@ -0,0 +1,181 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/projections"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendOrders(t *testing.T, store *postgres.PostgresEventStore, count int) []events.Event {
+	t.Helper()
+
+	batch := make([]events.Event, count)
+	for i := range batch {
+		batch[i] = orderEvent(uuid.New(), 1)
+	}
+
+	require.NoError(t, store.AppendEvents(context.Background(), batch))
+	return batch
+}
+
+func TestPostgresProjectionStore_CheckpointLifecycle(t *testing.T) {
+	db, _ := openTestDB(t)
+	eventStore := postgres.NewPostgresEventStore(db)
+	store := postgres.NewPostgresProjectionStore(db)
+	ctx := context.Background()
+
+	batch := appendOrders(t, eventStore, 3)
+
+	checkpoint, err := store.GetCheckpoint(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), checkpoint)
+
+	applied := 0
+	next, err := store.ApplyBatch(ctx, "orders", 0, batch, func(ctx context.Context, tx repository.Tx, event events.Event) error {
+		applied++
+		_, err := tx.ExecContext(ctx, `SELECT 1`)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, batch[2].Position, next)
+	assert.Equal(t, 3, applied)
+
+	checkpoint, err = store.GetCheckpoint(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, next, checkpoint)
+
+	// A runner behind the stored checkpoint must not apply the batch again
+	_, err = store.ApplyBatch(ctx, "orders", 0, batch, func(ctx context.Context, tx repository.Tx, event events.Event) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, repository.ErrCheckpointMoved)
+
+	// A failed batch leaves the checkpoint where it was
+	_, err = store.ApplyBatch(ctx, "orders", next, appendOrders(t, eventStore, 1), func(ctx context.Context, tx repository.Tx, event events.Event) error {
+		return errors.New("read model unavailable")
+	})
+	require.Error(t, err)
+
+	require.NoError(t, store.UpdateProjectionStatus(ctx, "orders", repository.ProjectionStalled, "read model unavailable"))
+
+	infos, err := store.ListProjections(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "orders", infos[0].Name)
+	assert.Equal(t, next, infos[0].Checkpoint)
+	assert.Equal(t, repository.ProjectionStalled, infos[0].Status)
+	assert.Equal(t, "read model unavailable", infos[0].LastError)
+
+	reset := false
+	require.NoError(t, store.ResetProjection(ctx, "orders", func(ctx context.Context, tx repository.Tx) error {
+		reset = true
+		return nil
+	}))
+	assert.True(t, reset)
+
+	infos, err = store.ListProjections(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, int64(0), infos[0].Checkpoint)
+	assert.Equal(t, repository.ProjectionRebuilding, infos[0].Status)
+	assert.Empty(t, infos[0].LastError)
+}
+
+func TestPostgresProjectionStore_NewProjectionIsRunning(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresProjectionStore(db)
+	ctx := context.Background()
+
+	_, err := store.GetCheckpoint(ctx, "orders")
+	require.NoError(t, err)
+
+	infos, err := store.ListProjections(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, repository.ProjectionRunning, infos[0].Status)
+}
+
+func TestPostgresProjectionStore_State(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresProjectionStore(db)
+	ctx := context.Background()
+
+	type counters struct {
+		Orders int `json:"orders"`
+	}
+
+	var state counters
+	assert.ErrorIs(t, store.GetProjectionState(ctx, "stats", &state), repository.ErrProjectionNotFound)
+	assert.ErrorIs(t, store.UpdateProjectionState(ctx, "stats", counters{Orders: 1}), repository.ErrProjectionNotFound)
+
+	require.NoError(t, store.SaveProjectionState(ctx, "stats", counters{Orders: 1}))
+	require.NoError(t, store.UpdateProjectionState(ctx, "stats", counters{Orders: 2}))
+
+	require.NoError(t, store.GetProjectionState(ctx, "stats", &state))
+	assert.Equal(t, 2, state.Orders)
+}
+
+func TestRunner_FollowsPostgresLog(t *testing.T) {
+	db, _ := openTestDB(t)
+	eventStore := postgres.NewPostgresEventStore(db)
+	store := postgres.NewPostgresProjectionStore(db)
+
+	appendOrders(t, eventStore, 3)
+
+	subscriber := repository.NewStoreSubscriber(eventStore, nil, repository.SubscriberConfig{
+		BatchSize:    2,
+		PollInterval: 10 * time.Millisecond,
+	})
+	runner := projections.NewRunner(subscriber, store, projections.RunnerConfig{
+		RetryInterval: 10 * time.Millisecond,
+	})
+
+	var applied int
+	require.NoError(t, runner.Register(projections.Projection{
+		Name: "orders",
+		Handlers: map[events.EventType]projections.Handler{
+			events.OrderCreatedEventType: func(ctx context.Context, tx repository.Tx, event events.Event) error {
+				applied++
+				return nil
+			},
+		},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	last := appendOrders(t, eventStore, 2)
+
+	require.Eventually(t, func() bool {
+		checkpoint, err := store.GetCheckpoint(context.Background(), "orders")
+		return err == nil && checkpoint == last[1].Position
+	}, 5*time.Second, 10*time.Millisecond)
+
+	infos, err := store.ListProjections(context.Background())
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, repository.ProjectionRunning, infos[0].Status)
+
+	cancel()
+	<-done
+	assert.Equal(t, 5, applied)
+}
@@ -0,0 +1,85 @@
+package postgres_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+	"github.com/HarshavardhanK/espm/pkg/compress"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresSnapshotStore_SaveAndLoadHistory(t *testing.T) {
+	db, _ := openTestDB(t)
+	store := postgres.NewPostgresSnapshotStore(db)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+
+	_, err := store.GetSnapshot(ctx, "Order", aggregateID, 0)
+	assert.ErrorIs(t, err, repository.ErrSnapshotNotFound)
+
+	for version := int64(1); version <= 3; version++ {
+		require.NoError(t, store.SaveSnapshot(ctx, "Order", aggregateID, version*10, 1, map[string]int64{"version": version}))
+	}
+
+	latest, err := store.GetSnapshot(ctx, "Order", aggregateID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), latest.Version)
+	assert.Equal(t, 1, latest.SchemaVersion)
+	assert.JSONEq(t, `{"version":3}`, string(latest.Data))
+
+	older, err := store.GetSnapshot(ctx, "Order", aggregateID, 30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), older.Version)
+
+	info, err := store.GetSnapshotInfo(ctx, "Order", aggregateID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), info.Version)
+
+	// Saving the same version again replaces it
+	require.NoError(t, store.SaveSnapshot(ctx, "Order", aggregateID, 30, 2, map[string]int64{"version": 4}))
+
+	outdated, err := store.ListOutdatedSnapshots(ctx, "Order", 2, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	pruned, err := store.PruneSnapshots(ctx, "Order", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pruned)
+
+	_, err = store.GetSnapshot(ctx, "Order", aggregateID, 30)
+	assert.ErrorIs(t, err, repository.ErrSnapshotNotFound)
+}
+
+func TestPostgresSnapshotStore_CompressesLargeSnapshots(t *testing.T) {
+	db, _ := openTestDB(t)
+
+	codec, err := compress.CodecFor(compress.Gzip)
+	require.NoError(t, err)
+
+	store := postgres.NewPostgresSnapshotStore(db).WithCompression(codec, 64)
+	ctx := context.Background()
+
+	aggregateID := uuid.New()
+	state := map[string]string{"items": string(bytes.Repeat([]byte("x"), 1024))}
+	require.NoError(t, store.SaveSnapshot(ctx, "Order", aggregateID, 1, 1, state))
+
+	var algorithm string
+	require.NoError(t, db.QueryRow(`SELECT compression FROM snapshots WHERE aggregate_id = $1`, aggregateID).Scan(&algorithm))
+	assert.Equal(t, string(compress.Gzip), algorithm)
+
+	snapshot, err := store.GetSnapshot(ctx, "Order", aggregateID, 0)
+	require.NoError(t, err)
+
+	var loaded map[string]string
+	require.NoError(t, json.Unmarshal(snapshot.Data, &loaded))
+	assert.Equal(t, state, loaded)
+}
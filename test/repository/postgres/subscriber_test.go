@@ -0,0 +1,75 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+	"github.com/HarshavardhanK/espm/internal/repository/postgres"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresNotifier_DoesNotWaitForConnection(t *testing.T) {
+	notifier := postgres.NewPostgresNotifier("postgres://espm@127.0.0.1:1/espm?sslmode=disable", time.Millisecond, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	returned := make(chan bool, 1)
+	go func() {
+		_, live, err := notifier.Notify(ctx)
+		assert.NoError(t, err)
+		returned <- live()
+	}()
+
+	select {
+	case live := <-returned:
+		assert.False(t, live)
+	case <-time.After(time.Second):
+		t.Fatal("Notify blocked on an unreachable database")
+	}
+}
+
+func TestPostgresSubscriber_DeliversCommittedEvents(t *testing.T) {
+	db, connStr := openTestDB(t)
+	store := postgres.NewPostgresEventStore(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	aggregateID := uuid.New()
+	_, err := store.AppendToStream(ctx, "Order", aggregateID, repository.ExpectedVersionNoStream,
+		[]events.Event{orderEvent(aggregateID, 0)})
+	require.NoError(t, err)
+
+	notifier := postgres.NewPostgresNotifier(connStr, 10*time.Millisecond, time.Second)
+	subscriber := repository.NewStoreSubscriber(store, notifier, repository.SubscriberConfig{BatchSize: 10, PollInterval: time.Hour})
+
+	received := make(chan events.Event, 10)
+	go subscriber.Subscribe(ctx, 0, func(event events.Event) error {
+		received <- event
+		return nil
+	})
+
+	// The event committed before subscribing arrives through the catch-up
+	first := <-received
+	assert.Equal(t, int64(1), first.Sequence)
+
+	// With polling effectively off, a later commit can only arrive by notification
+	_, err = store.AppendToStream(ctx, "Order", aggregateID, 1, []events.Event{orderEvent(aggregateID, 0)})
+	require.NoError(t, err)
+
+	select {
+	case second := <-received:
+		assert.Equal(t, int64(2), second.Sequence)
+		assert.Greater(t, second.Position, first.Position)
+	case <-time.After(5 * time.Second):
+		t.Fatal("committed event was not delivered")
+	}
+}
@@ -0,0 +1,235 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HarshavardhanK/espm/internal/events"
+	"github.com/HarshavardhanK/espm/internal/repository"
+
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLog is an in-memory global event log. overlap makes every read start
+// that many events before the requested position, as a lagging replica might.
+type memoryLog struct {
+	MockEventStore
+
+	mu      sync.Mutex
+	events  []events.Event
+	overlap int
+}
+
+func (l *memoryLog) append(count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := 0; i < count; i++ {
+		event := events.NewEvent("Order", uuid.New(), events.OrderCreatedEventType, 1, 1, []byte(`{}`), nil)
+		event.Position = int64(len(l.events) + 1)
+		l.events = append(l.events, event)
+	}
+}
+
+func (l *memoryLog) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]events.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := int(fromPosition) - l.overlap
+	if start < 0 {
+		start = 0
+	}
+	if start > len(l.events) {
+		start = len(l.events)
+	}
+
+	end := start + limit
+	if end > len(l.events) {
+		end = len(l.events)
+	}
+
+	return append([]events.Event(nil), l.events[start:end]...), nil
+}
+
+// fakeNotifier signals commits on demand
+type fakeNotifier struct {
+	signals chan struct{}
+	live    atomic.Bool
+}
+
+func newFakeNotifier(live bool) *fakeNotifier {
+	n := &fakeNotifier{signals: make(chan struct{}, 1)}
+	n.live.Store(live)
+	return n
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context) (<-chan struct{}, func() bool, error) {
+	return n.signals, n.live.Load, nil
+}
+
+func (n *fakeNotifier) signal() {
+	select {
+	case n.signals <- struct{}{}:
+	default:
+	}
+}
+
+// collector records the positions a subscription delivers
+type collector struct {
+	mu        sync.Mutex
+	positions []int64
+}
+
+func (c *collector) handle(event events.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.positions = append(c.positions, event.Position)
+	return nil
+}
+
+func (c *collector) delivered() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int64(nil), c.positions...)
+}
+
+func positions(from, to int64) []int64 {
+	var result []int64
+	for p := from; p <= to; p++ {
+		result = append(result, p)
+	}
+	return result
+}
+
+func TestStoreSubscriber_CatchesUpThenFollowsCommits(t *testing.T) {
+
+	log := &memoryLog{}
+	log.append(5)
+
+	notifier := newFakeNotifier(true)
+	subscriber := repository.NewStoreSubscriber(log, notifier, repository.SubscriberConfig{BatchSize: 2, PollInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := &collector{}
+	done := make(chan error, 1)
+	go func() { done <- subscriber.Subscribe(ctx, 1, received.handle) }()
+
+	require.Eventually(t, func() bool { return len(received.delivered()) == 4 }, time.Second, time.Millisecond)
+	assert.Equal(t, positions(2, 5), received.delivered())
+
+	// Live events only arrive once a commit is signalled
+	log.append(3)
+	notifier.signal()
+
+	require.Eventually(t, func() bool { return len(received.delivered()) == 7 }, time.Second, time.Millisecond)
+	assert.Equal(t, positions(2, 8), received.delivered())
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestStoreSubscriber_NeverRepeatsPositionsAcrossHandoff(t *testing.T) {
+
+	// Every read overlaps the previous one by two events
+	log := &memoryLog{overlap: 2}
+	log.append(4)
+
+	notifier := newFakeNotifier(true)
+	subscriber := repository.NewStoreSubscriber(log, notifier, repository.SubscriberConfig{BatchSize: 3, PollInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := &collector{}
+	handedOff := false
+
+	go subscriber.Subscribe(ctx, 0, func(event events.Event) error {
+		// A commit signalled while still catching up is read by both the
+		// catch-up and the live read that follows
+		if !handedOff {
+			handedOff = true
+			log.append(2)
+			notifier.signal()
+		}
+		return received.handle(event)
+	})
+
+	require.Eventually(t, func() bool { return len(received.delivered()) == 6 }, time.Second, time.Millisecond)
+
+	log.append(1)
+	notifier.signal()
+
+	require.Eventually(t, func() bool { return len(received.delivered()) == 7 }, time.Second, time.Millisecond)
+	assert.Equal(t, positions(1, 7), received.delivered())
+}
+
+func TestStoreSubscriber_PollsWhileNotificationsAreDown(t *testing.T) {
+
+	log := &memoryLog{}
+	notifier := newFakeNotifier(false)
+	subscriber := repository.NewStoreSubscriber(log, notifier, repository.SubscriberConfig{BatchSize: 10, PollInterval: 5 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := &collector{}
+	go subscriber.Subscribe(ctx, 0, received.handle)
+
+	// No signal is sent; the poll picks the event up
+	log.append(1)
+
+	require.Eventually(t, func() bool { return len(received.delivered()) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestStoreSubscriber_HandlerErrorEndsSubscription(t *testing.T) {
+
+	log := &memoryLog{}
+	log.append(5)
+
+	notifier := newFakeNotifier(true)
+	subscriber := repository.NewStoreSubscriber(log, notifier, repository.SubscriberConfig{BatchSize: 10, PollInterval: time.Hour})
+
+	errHandler := errors.New("projection failed")
+	received := &collector{}
+
+	err := subscriber.Subscribe(context.Background(), 0, func(event events.Event) error {
+		if event.Position == 3 {
+			return errHandler
+		}
+		return received.handle(event)
+	})
+
+	assert.ErrorIs(t, err, errHandler)
+	assert.Equal(t, positions(1, 2), received.delivered())
+}
+
+func TestStoreSubscriber_SignalsCaughtUpWithEmptyBatch(t *testing.T) {
+
+	log := &memoryLog{}
+	log.append(3)
+
+	subscriber := repository.NewStoreSubscriber(log, nil, repository.SubscriberConfig{BatchSize: 2, PollInterval: time.Hour})
+
+	var sizes []int
+	errStop := errors.New("stop")
+
+	err := subscriber.SubscribeBatches(context.Background(), 0, func(batch []events.Event) error {
+		sizes = append(sizes, len(batch))
+		if len(batch) == 0 {
+			return errStop
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, []int{2, 1, 0}, sizes)
+}
@@ -0,0 +1,47 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/HarshavardhanK/espm/pkg/telemetry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WritesPrometheusText(t *testing.T) {
+	registry := telemetry.NewRegistry()
+
+	counter := registry.NewCounter("test_requests_total", "Requests served.", "route")
+	counter.Inc("/orders")
+	counter.Add(2, "/orders")
+
+	histogram := registry.NewHistogram("test_replay_events", "Events replayed.", []float64{1, 10}, "aggregate_type")
+	histogram.Observe(4, "Order")
+
+	var out bytes.Buffer
+	registry.Write(&out)
+
+	assert.Equal(t, `# HELP test_replay_events Events replayed.
+# TYPE test_replay_events histogram
+test_replay_events_bucket{aggregate_type="Order",le="1"} 0
+test_replay_events_bucket{aggregate_type="Order",le="10"} 1
+test_replay_events_bucket{aggregate_type="Order",le="+Inf"} 1
+test_replay_events_sum{aggregate_type="Order"} 4
+test_replay_events_count{aggregate_type="Order"} 1
+# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{route="/orders"} 3
+`, out.String())
+}
+
+func TestRegistry_ReturnsExistingMetric(t *testing.T) {
+	registry := telemetry.NewRegistry()
+
+	first := registry.NewCounter("test_total", "Total.")
+	second := registry.NewCounter("test_total", "Total.")
+	second.Inc()
+
+	assert.Same(t, first, second)
+	assert.Equal(t, 1.0, first.Value())
+}